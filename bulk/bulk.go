@@ -0,0 +1,361 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package bulk loads records into SQL databases with their bulk
+// loading protocols, which requires importing their drivers.
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Zaba505/tblconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Method selects how a Writer loads records into a database.
+type Method uint8
+
+const (
+	// PostgresCopy loads records with COPY ... FROM STDIN, using the
+	// CopyIn support of the lib/pq driver.
+	PostgresCopy Method = iota
+
+	// MySQLLoadData loads records with LOAD DATA LOCAL INFILE, streaming
+	// them through a reader handler registered with the go-sql-driver/mysql
+	// driver. The server must allow local_infile.
+	MySQLLoadData
+)
+
+// these are replaced in tests, since only the MySQL driver can read
+// from its reader handlers
+var (
+	registerReaderHandler   = mysql.RegisterReaderHandler
+	deregisterReaderHandler = mysql.DeregisterReaderHandler
+)
+
+var readerHandlers int64
+
+// errAborted is the error of a LOAD DATA whose writer was aborted.
+var errAborted = errors.New("bulk: load aborted")
+
+// Writer loads records into a table with the bulk loading
+// protocol of a database, which is much faster than inserting
+// them one at a time with a tblconv.SQLWriter.
+//
+// Records are loaded into the given columns of the table, or else into
+// the columns named by the schema given to WriteSchema, or else into
+// the columns named by the first record, which is taken as a header row
+// and not loaded itself.
+//
+// Like a tblconv.SQLWriter, records are loaded within a sql.Tx, which
+// is bound to the context of the first write and committed by Flush.
+// Unlike a SQLWriter, a single record failing to load fails the whole
+// load, which is only reported once the load is flushed.
+type Writer struct {
+	db      *sql.DB
+	method  Method
+	table   string
+	columns []string
+
+	tx *sql.Tx
+
+	// ctx is the context the sql.Tx is bound to
+	ctx context.Context
+
+	// PostgresCopy
+	stmt *sql.Stmt
+
+	// MySQLLoadData
+	handler string
+	pipe    *io.PipeWriter
+	buf     *bufio.Writer
+	done    chan error
+}
+
+// NewWriter
+func NewWriter(db *sql.DB, method Method, table string, columns ...string) *Writer {
+	return &Writer{
+		db:      db,
+		method:  method,
+		table:   table,
+		columns: columns,
+	}
+}
+
+// WriteSchema sets the columns to load records into, unless
+// columns were given to NewWriter.
+func (w *Writer) WriteSchema(columns []tblconv.Column) error {
+	if len(w.columns) > 0 {
+		return nil
+	}
+
+	for _, col := range columns {
+		w.columns = append(w.columns, col.Name)
+	}
+	return nil
+}
+
+// Write
+func (w *Writer) Write(record []string) error {
+	return w.WriteContext(context.Background(), record)
+}
+
+// WriteContext is the same as Write except the load is started with
+// the given context, if this is the first write since creation or
+// a Flush.
+func (w *Writer) WriteContext(ctx context.Context, record []string) error {
+	if len(w.columns) == 0 {
+		w.columns = append(w.columns, record...)
+		return nil
+	}
+
+	args := make([]interface{}, len(record))
+	for i, field := range record {
+		args[i] = field
+	}
+	return w.load(ctx, args)
+}
+
+// WriteTyped
+func (w *Writer) WriteTyped(record []tblconv.Value) error {
+	return w.WriteTypedContext(context.Background(), record)
+}
+
+// WriteTypedContext is the same as WriteContext except values are
+// loaded as their native type. NULL values are loaded as NULL.
+func (w *Writer) WriteTypedContext(ctx context.Context, record []tblconv.Value) error {
+	if len(w.columns) == 0 {
+		for _, val := range record {
+			w.columns = append(w.columns, val.String())
+		}
+		return nil
+	}
+
+	args := make([]interface{}, len(record))
+	for i, val := range record {
+		switch {
+		case val.Null:
+			args[i] = nil
+		case w.method == MySQLLoadData:
+			args[i] = loadDataValue(val)
+		default:
+			args[i] = val.V
+		}
+	}
+	return w.load(ctx, args)
+}
+
+func (w *Writer) load(ctx context.Context, args []interface{}) error {
+	if w.tx == nil {
+		err := w.start(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.method == PostgresCopy {
+		_, err := w.stmt.ExecContext(ctx, args...)
+		return err
+	}
+
+	for i, arg := range args {
+		if i > 0 {
+			w.buf.WriteByte('\t')
+		}
+		writeLoadDataField(w.buf, arg)
+	}
+	return w.buf.WriteByte('\n')
+}
+
+func (w *Writer) start(ctx context.Context) (err error) {
+	w.tx, err = w.db.BeginTx(ctx, nil)
+	if err != nil {
+		w.tx = nil
+		return
+	}
+	w.ctx = ctx
+
+	switch w.method {
+	case PostgresCopy:
+		w.stmt, err = w.tx.PrepareContext(ctx, copyInStatement(w.table, w.columns))
+	case MySQLLoadData:
+		w.handler = "tblconv_" + strconv.FormatInt(atomic.AddInt64(&readerHandlers, 1), 10)
+		pr, pw := io.Pipe()
+		registerReaderHandler(w.handler, func() io.Reader { return pr })
+
+		w.pipe = pw
+		w.buf = bufio.NewWriter(pw)
+		w.done = make(chan error, 1)
+		go func(tx *sql.Tx, query string, done chan<- error) {
+			// once the load is done, any further writes fail
+			_, err := tx.ExecContext(ctx, query)
+			if err != nil {
+				pr.CloseWithError(err)
+			} else {
+				pr.Close()
+			}
+			done <- err
+		}(w.tx, loadDataStatement(w.handler, w.table, w.columns), w.done)
+	default:
+		err = fmt.Errorf("bulk: unknown method: %d", w.method)
+	}
+	if err != nil {
+		w.tx.Rollback()
+		w.tx = nil
+	}
+	return
+}
+
+// Abort rolls back the underlying sql.Tx, discarding every record
+// written since the last Flush.
+func (w *Writer) Abort() error {
+	if w.tx == nil {
+		return nil
+	}
+
+	switch w.method {
+	case PostgresCopy:
+		w.stmt.Close()
+	case MySQLLoadData:
+		w.pipe.CloseWithError(errAborted)
+		<-w.done
+		deregisterReaderHandler(w.handler)
+	}
+
+	tx := w.tx
+	w.tx = nil
+	return tx.Rollback()
+}
+
+// Flush completes the load and commits the underlying sql.Tx, or rolls
+// it back if the load failed. Flushing a Writer without any records
+// written since the last Flush does nothing.
+func (w *Writer) Flush() error {
+	if w.tx == nil {
+		return nil
+	}
+
+	var err error
+	switch w.method {
+	case PostgresCopy:
+		_, err = w.stmt.ExecContext(w.ctx)
+		if cerr := w.stmt.Close(); err == nil {
+			err = cerr
+		}
+	case MySQLLoadData:
+		err = w.buf.Flush()
+		w.pipe.CloseWithError(err)
+		if lerr := <-w.done; lerr != nil {
+			err = lerr
+		}
+		deregisterReaderHandler(w.handler)
+	}
+
+	tx := w.tx
+	w.tx = nil
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// copyInStatement returns the COPY statement for lib/pq's CopyIn,
+// quoting identifiers like a tblconv.SQLWriter inserting into the table.
+func copyInStatement(table string, columns []string) string {
+	d := tblconv.PostgresDialect
+	return "COPY " + d.QuoteTable(table) + " (" + quoteColumns(d, columns) + ") FROM STDIN"
+}
+
+// loadDataStatement returns the LOAD DATA statement reading from the
+// reader handler. Fields are tab separated and escaped with a backslash,
+// which is the default of LOAD DATA.
+func loadDataStatement(handler, table string, columns []string) string {
+	d := tblconv.MySQLDialect
+	return "LOAD DATA LOCAL INFILE 'Reader::" + handler + "' INTO TABLE " + d.QuoteTable(table) +
+		" CHARACTER SET utf8mb4 (" + quoteColumns(d, columns) + ")"
+}
+
+func quoteColumns(d tblconv.Dialect, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.QuoteIdentifier(col)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// loadDataValue formats a value as LOAD DATA expects it.
+func loadDataValue(val tblconv.Value) string {
+	switch x := val.V.(type) {
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		if val.Kind == tblconv.DateKind {
+			return x.Format("2006-01-02")
+		}
+		return x.Format("2006-01-02 15:04:05.999999")
+	case []byte:
+		return string(x)
+	default:
+		return val.String()
+	}
+}
+
+// writeLoadDataField writes a field in the default format of LOAD DATA,
+// where NULL is written as \N.
+func writeLoadDataField(buf *bufio.Writer, v interface{}) {
+	s, ok := v.(string)
+	if !ok {
+		buf.WriteString(`\N`)
+		return
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case 0:
+			buf.WriteString(`\0`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
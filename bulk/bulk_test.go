@@ -0,0 +1,291 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Zaba505/tblconv"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestWriterPostgres(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Table     string
+		Columns   []string
+		Reader    func() tblconv.Reader
+		Statement string
+	}{
+		{
+			Name:      "Columns",
+			Table:     "users",
+			Columns:   []string{"id", "name"},
+			Reader:    func() tblconv.Reader { return tblconv.NewRecordsReader([]string{"0", "tony"}, []string{"1", "clark"}) },
+			Statement: `COPY users (id, name) FROM STDIN`,
+		},
+		{
+			Name:  "Schema",
+			Table: "users",
+			Reader: func() tblconv.Reader {
+				return tblconv.NewCSVReader(strings.NewReader("id,name\n0,tony\n1,clark\n"), tblconv.CSVHeader(true))
+			},
+			Statement: `COPY users (id, name) FROM STDIN`,
+		},
+		{
+			Name:  "Header",
+			Table: "users",
+			Reader: func() tblconv.Reader {
+				return tblconv.NewRecordsReader([]string{"UserId", "order"}, []string{"0", "tony"}, []string{"1", "clark"})
+			},
+			Statement: `COPY users (UserId, "order") FROM STDIN`,
+		},
+		{
+			Name:      "QualifiedTable",
+			Table:     "public.users",
+			Columns:   []string{"id", "name"},
+			Reader:    func() tblconv.Reader { return tblconv.NewRecordsReader([]string{"0", "tony"}, []string{"1", "clark"}) },
+			Statement: `COPY public.users (id, name) FROM STDIN`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				subT.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			stmt := mock.ExpectPrepare(testCase.Statement)
+			stmt.ExpectExec().WithArgs("0", "tony").WillReturnResult(sqlmock.NewResult(0, 0))
+			stmt.ExpectExec().WithArgs("1", "clark").WillReturnResult(sqlmock.NewResult(0, 0))
+			stmt.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectCommit()
+
+			err = tblconv.Copy(NewWriter(db, PostgresCopy, testCase.Table, testCase.Columns...), testCase.Reader())
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				subT.Logf("unmet expectation error: %s", err)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+// loadDataDriver stands in for the MySQL driver, reading LOAD DATA
+// LOCAL INFILE statements from the registered reader handlers.
+type loadDataDriver struct {
+	mu        sync.Mutex
+	handlers  map[string]func() io.Reader
+	statement string
+	data      string
+	committed bool
+	err       error
+}
+
+func (d *loadDataDriver) Open(string) (driver.Conn, error)             { return d, nil }
+func (d *loadDataDriver) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d *loadDataDriver) Driver() driver.Driver                        { return d }
+func (d *loadDataDriver) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (d *loadDataDriver) Close() error              { return nil }
+func (d *loadDataDriver) Begin() (driver.Tx, error) { return d, nil }
+func (d *loadDataDriver) Rollback() error           { return nil }
+
+func (d *loadDataDriver) Commit() error {
+	d.committed = true
+	return nil
+}
+
+var loadDataHandler = regexp.MustCompile(`'Reader::([^']+)'`)
+
+func (d *loadDataDriver) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	d.mu.Lock()
+	handler := d.handlers[loadDataHandler.FindStringSubmatch(query)[1]]
+	d.mu.Unlock()
+
+	b, err := io.ReadAll(handler())
+	if err != nil {
+		return nil, err
+	}
+	d.statement = query
+	d.data = string(b)
+	return driver.RowsAffected(0), nil
+}
+
+func TestWriterMySQL(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Table     string
+		Columns   []string
+		Reader    func() tblconv.Reader
+		Err       error
+		Statement string
+		Data      string
+	}{
+		{
+			Name:    "Strings",
+			Table:   "users",
+			Columns: []string{"id", "name"},
+			Reader: func() tblconv.Reader {
+				return tblconv.NewRecordsReader([]string{"0", "tony\tstark"}, []string{"1", `c:\kent`}, []string{"2", ""})
+			},
+			Statement: "LOAD DATA LOCAL INFILE 'Reader::tblconv_1' INTO TABLE users CHARACTER SET utf8mb4 (id, name)",
+			Data:      "0\ttony\\tstark\n1\tc:\\\\kent\n2\t\n",
+		},
+		{
+			Name:  "Typed",
+			Table: "db.users",
+			Reader: func() tblconv.Reader {
+				return tblconv.NewInferReader(tblconv.NewRecordsReader(
+					[]string{"id", "active", "born", "seen"},
+					[]string{"0", "true", "1970-05-29", "2022-01-02 15:04:05"},
+					[]string{"1", "", "1980-01-31", ""},
+				))
+			},
+			Statement: "LOAD DATA LOCAL INFILE 'Reader::tblconv_2' INTO TABLE db.users CHARACTER SET utf8mb4 (id, active, born, seen)",
+			Data:      "0\t1\t1970-05-29\t2022-01-02 15:04:05\n1\t\\N\t1980-01-31\t\\N\n",
+		},
+		{
+			Name:    "Failed",
+			Table:   "users",
+			Columns: []string{"id"},
+			Reader:  func() tblconv.Reader { return tblconv.NewRecordsReader([]string{"0"}) },
+			Err:     errors.New("local_infile is disabled"),
+		},
+	}
+
+	register, deregister, handlers := registerReaderHandler, deregisterReaderHandler, readerHandlers
+	t.Cleanup(func() {
+		registerReaderHandler, deregisterReaderHandler, readerHandlers = register, deregister, handlers
+	})
+
+	d := &loadDataDriver{handlers: make(map[string]func() io.Reader)}
+	registerReaderHandler = func(name string, handler func() io.Reader) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.handlers[name] = handler
+	}
+	deregisterReaderHandler = func(name string) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.handlers, name)
+	}
+	readerHandlers = 0
+
+	db := sql.OpenDB(d)
+	defer db.Close()
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			d.committed = false
+			d.err = testCase.Err
+
+			err := tblconv.Copy(NewWriter(db, MySQLLoadData, testCase.Table, testCase.Columns...), testCase.Reader())
+			if testCase.Err != nil {
+				if err == nil || err.Error() != testCase.Err.Error() || d.committed {
+					subT.Logf("expected error: %s\ngot: %v", testCase.Err, err)
+					subT.Fail()
+				}
+				return
+			}
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if !d.committed {
+				subT.Log("expected the load to be committed")
+				subT.Fail()
+			}
+			if d.statement != testCase.Statement {
+				subT.Logf("expected: %s\ngot: %s", testCase.Statement, d.statement)
+				subT.Fail()
+			}
+			if d.data != testCase.Data {
+				subT.Logf("expected: %q\ngot: %q", testCase.Data, d.data)
+				subT.Fail()
+			}
+			if len(d.handlers) > 0 {
+				subT.Logf("expected handlers to be deregistered: %v", d.handlers)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestWriterFlushCanceled(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare(`COPY users (id) FROM STDIN`)
+	stmt.ExpectExec().WithArgs("0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewWriter(db, PostgresCopy, "users", "id")
+	err = w.WriteContext(ctx, []string{"0"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cancel()
+	err = w.Flush()
+	if err != context.Canceled {
+		t.Logf("expected: %v\ngot: %v", context.Canceled, err)
+		t.Fail()
+		return
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Logf("unmet expectation error: %s", err)
+		t.Fail()
+	}
+}
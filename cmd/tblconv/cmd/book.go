@@ -0,0 +1,248 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/Zaba505/tblconv"
+	"github.com/Zaba505/tblconv/cmd/tblconv/cmd/flags"
+	"github.com/Zaba505/tblconv/cmd/tblconv/cmd/source"
+
+	"github.com/spf13/cobra"
+)
+
+var bookCmd = &cobra.Command{
+	Use:   "excel-book",
+	Short: "Write several sources into one Excel workbook, one sheet each.",
+	Long: `Write several sources into one Excel workbook, one sheet each.
+
+Each sheet is given as NAME=SOURCE, where SOURCE is either a .csv, .tsv,
+.xlsx, .xls or .ods file, or sql:QUERY to read the results of a query from
+the database given by --sql-server and --dsn.
+
+--header, --delimiter and --encoding apply to every .csv and .tsv source,
+and --header to every spreadsheet source, whose first sheet is read.`,
+	Example: `  tblconv excel-book -o report.xlsx --sheet users=users.csv --sheet orders="sql:SELECT * FROM orders"`,
+	Args:    cobra.NoArgs,
+	Run:     runBook,
+}
+
+func init() {
+	bookCmd.Flags().StringP("output", "o", "", "Filename to write the workbook to.")
+	bookCmd.Flags().StringArray("sheet", []string{}, "Sheet to write, given as NAME=SOURCE. May be repeated.")
+	bookCmd.Flags().StringP("sql-server", "s", "", "SQL server for sql: sources")
+	bookCmd.Flags().String("dsn", "", "Database endpoint for sql: sources")
+	bookCmd.Flags().Bool("header", false, "Treat the first row of file sources as column names.")
+	flags.Rune(bookCmd.Flags(), "delimiter", ',', "Field delimiter of .csv sources. Defaults to a tab for .tsv sources.")
+	bookCmd.Flags().String("encoding", "auto", "Character encoding of .csv and .tsv sources (possible values: auto, utf-8, utf-8-bom, utf-16le, utf-16be, latin1, windows-1252).")
+
+	bookCmd.MarkFlagRequired("sheet")
+
+	rootCmd.AddCommand(bookCmd)
+}
+
+func runBook(cmd *cobra.Command, args []string) {
+	outputName, err := cmd.Flags().GetString("output")
+	if err != nil {
+		panic(err)
+	}
+
+	sheets, err := cmd.Flags().GetStringArray("sheet")
+	if err != nil {
+		panic(err)
+	}
+
+	// the workbook is written to a temporary file, which only replaces
+	// the output once every sheet has been written
+	dst := os.Stdout
+	if strings.TrimSpace(outputName) != "" {
+		dst, err = os.CreateTemp(filepath.Dir(outputName), "."+filepath.Base(outputName)+".*")
+		if err != nil {
+			panic(err)
+		}
+		defer os.Remove(dst.Name())
+		defer dst.Close()
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	b := tblconv.NewWorkbook(dst)
+	for _, sheet := range sheets {
+		name, src, err := splitPair(sheet)
+		if err != nil {
+			panic(err)
+		}
+
+		r, f, err := sheetReader(cmd, src)
+		if err != nil {
+			panic(err)
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+		b.AddSheet(name, r)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = b.WriteContext(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	if dst != os.Stdout {
+		err = dst.Close()
+		if err != nil {
+			panic(err)
+		}
+		err = os.Rename(dst.Name(), outputName)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// closeOnEOF closes a source file once it has been read to the end,
+// which is when the sheet it is read into has been written.
+type closeOnEOF struct {
+	f   *os.File
+	eof bool
+}
+
+func (r *closeOnEOF) Read(p []byte) (int, error) {
+	if r.eof {
+		return 0, io.EOF
+	}
+
+	n, err := r.f.Read(p)
+	if err == io.EOF {
+		r.eof = true
+		r.f.Close()
+	}
+	return n, err
+}
+
+// sheetReader returns the Reader of a source, along with
+// the file it reads from, unless it is a sql: source.
+func sheetReader(cmd *cobra.Command, src string) (tblconv.Reader, *os.File, error) {
+	if query := strings.TrimPrefix(src, "sql:"); query != src {
+		server, err := cmd.Flags().GetString("sql-server")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dsn, err := cmd.Flags().GetString("dsn")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if server == "" || dsn == "" {
+			return nil, nil, fmt.Errorf("--sql-server and --dsn are required for sql: sources")
+		}
+
+		db, err := source.OpenDB(server, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tblconv.NewSQLReader(db, query), nil, nil
+	}
+
+	header, err := cmd.Flags().GetBool("header")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var headerRow int
+	if header {
+		headerRow = 1
+	}
+
+	ext := strings.ToLower(filepath.Ext(src))
+	switch ext {
+	case ".csv", ".tsv", ".xlsx", ".xlsm", ".xls", ".ods":
+	default:
+		return nil, nil, fmt.Errorf("unknown source format: %s", src)
+	}
+
+	f, err := open(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &closeOnEOF{f: f}
+
+	switch ext {
+	case ".csv", ".tsv":
+		opts, err := csvOptions(cmd, ext)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return tblconv.NewCSVReader(r, append(opts, tblconv.CSVHeader(header))...), f, nil
+	}
+
+	opts := []tblconv.ExcelOption{tblconv.SheetIndex(1), tblconv.HeaderRow(headerRow)}
+	switch ext {
+	case ".xls":
+		return tblconv.NewXLSReader(r, opts...), f, nil
+	case ".ods":
+		return tblconv.NewODSReader(r, opts...), f, nil
+	default:
+		return tblconv.NewExcelReader(r, opts...), f, nil
+	}
+}
+
+// csvOptions returns the options of a .csv or .tsv source.
+func csvOptions(cmd *cobra.Command, ext string) ([]tblconv.CSVOption, error) {
+	delim, err := flags.GetRune(cmd.Flags(), "delimiter")
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".tsv" && !cmd.Flags().Changed("delimiter") {
+		delim = '\t'
+	}
+
+	encoding, err := cmd.Flags().GetString("encoding")
+	if err != nil {
+		return nil, err
+	}
+	enc, err := tblconv.ParseEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	return []tblconv.CSVOption{tblconv.CSVDelimiter(delim), tblconv.CSVEncoding(enc)}, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package flags provides the flag types shared by the tblconv commands.
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/spf13/pflag"
+)
+
+// runeValue is a single character flag, where "tab" and "\t" both mean a tab.
+type runeValue rune
+
+// Set
+func (r *runeValue) Set(s string) error {
+	if s == "tab" || s == `\t` {
+		*r = '\t'
+		return nil
+	}
+
+	if utf8.RuneCountInString(s) != 1 {
+		return errors.New("must be a single character")
+	}
+	c, _ := utf8.DecodeRuneInString(s)
+	*r = runeValue(c)
+	return nil
+}
+
+// String
+func (r *runeValue) String() string {
+	switch *r {
+	case 0:
+		return ""
+	case '\t':
+		return "tab"
+	}
+	return string(rune(*r))
+}
+
+// Type
+func (r *runeValue) Type() string {
+	return "char"
+}
+
+// Rune defines a single character flag with the given default, where
+// 0 is no character. Setting the flag to anything but a single
+// character, or "tab" or "\t" for a tab, is a flag error.
+func Rune(fs *pflag.FlagSet, name string, value rune, usage string) {
+	r := runeValue(value)
+	fs.Var(&r, name, usage)
+}
+
+// GetRune returns the value of a flag defined by Rune.
+func GetRune(fs *pflag.FlagSet, name string) (rune, error) {
+	f := fs.Lookup(name)
+	if f == nil {
+		return 0, fmt.Errorf("flag accessed but not defined: %s", name)
+	}
+
+	r, ok := f.Value.(*runeValue)
+	if !ok {
+		return 0, fmt.Errorf("trying to get char value of flag of type %s", f.Value.Type())
+	}
+	return rune(*r), nil
+}
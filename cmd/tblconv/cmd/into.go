@@ -23,9 +23,14 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Zaba505/tblconv"
 	"github.com/Zaba505/tblconv/cmd/tblconv/cmd/output"
@@ -47,6 +52,14 @@ func init() {
 
 		for _, outCmd := range output.Commands() {
 			outCmd.Run = runConvert
+			outCmd.Flags().Int("buffer-size", 0, "Number of records to buffer between reading and writing concurrently (0 disables concurrency).")
+			outCmd.Flags().Bool("progress", false, "Report progress on stderr.")
+			outCmd.Flags().Int("max-errors", 0, "Number of failed records to skip before aborting (-1 for no limit).")
+			outCmd.Flags().String("reject", "", "Filename to write failed records to as CSV, with the error message as last column. Implies --max-errors=-1 unless set.")
+			outCmd.Flags().Int("infer", 0, "Number of records to sample for inferring column types (0 disables inference).")
+			outCmd.Flags().StringSlice("null", []string{}, "Field values read as NULL when inferring column types, in addition to empty fields.")
+			outCmd.Flags().StringSlice("date-layout", []string{}, "Date layouts, in Go time format, recognized when inferring column types.")
+			addTransformFlags(outCmd)
 
 			intoCmd.AddCommand(outCmd)
 		}
@@ -75,14 +88,119 @@ func runConvert(outCmd *cobra.Command, args []string) {
 		}
 	}
 
+	bufferSize, err := outCmd.Flags().GetInt("buffer-size")
+	if err != nil {
+		panic(err)
+	}
+
+	progress, err := outCmd.Flags().GetBool("progress")
+	if err != nil {
+		panic(err)
+	}
+
+	maxErrors, err := outCmd.Flags().GetInt("max-errors")
+	if err != nil {
+		panic(err)
+	}
+
+	rejectName, err := outCmd.Flags().GetString("reject")
+	if err != nil {
+		panic(err)
+	}
+
+	opts := []tblconv.CopyOption{tblconv.Pipeline(bufferSize)}
+	if strings.TrimSpace(rejectName) != "" {
+		rejects, err := os.Create(rejectName)
+		if err != nil {
+			panic(err)
+		}
+		defer rejects.Close()
+
+		if !outCmd.Flags().Changed("max-errors") {
+			maxErrors = -1
+		}
+		opts = append(opts, tblconv.RejectTo(tblconv.NewCSVWriter(rejects)))
+	}
+	if maxErrors != 0 {
+		opts = append(opts, tblconv.SkipErrors(maxErrors))
+	}
+	if progress {
+		opts = append(opts, tblconv.Progress(time.Second, printProgress))
+		defer fmt.Fprintln(os.Stderr)
+	}
+
 	srcCmd := outCmd.Parent().Parent()
 	w := output.Writer(outCmd.Name(), dst, outCmd)
 	r := source.Reader(srcCmd.Name(), src, srcCmd)
 
-	err = tblconv.Copy(w, r)
+	var null string
+	if f := srcCmd.Flags().Lookup("null-as"); f != nil {
+		null = f.Value.String()
+	}
+
+	ts, err := transforms(outCmd, null)
 	if err != nil {
 		panic(err)
 	}
+	if len(ts) > 0 {
+		r = tblconv.NewTransformReader(r, ts...)
+	}
+
+	infer, err := outCmd.Flags().GetInt("infer")
+	if err != nil {
+		panic(err)
+	}
+	if infer > 0 {
+		nulls, err := outCmd.Flags().GetStringSlice("null")
+		if err != nil {
+			panic(err)
+		}
+
+		dateLayouts, err := outCmd.Flags().GetStringSlice("date-layout")
+		if err != nil {
+			panic(err)
+		}
+
+		inferOpts := []tblconv.InferOption{tblconv.SampleSize(infer), tblconv.NullTokens(nulls...)}
+		if len(dateLayouts) > 0 {
+			inferOpts = append(inferOpts, tblconv.DateLayouts(dateLayouts...))
+		}
+		r = tblconv.NewInferReader(r, inferOpts...)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = tblconv.CopyContext(ctx, w, r, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func printProgress(stats tblconv.Stats) {
+	fmt.Fprintf(
+		os.Stderr,
+		"\r%d read, %d written, %d skipped, %s in %s",
+		stats.RecordsRead,
+		stats.RecordsWritten,
+		stats.RecordsSkipped,
+		formatBytes(stats.Bytes),
+		stats.Elapsed.Round(time.Second),
+	)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
 func open(name string) (*os.File, error) {
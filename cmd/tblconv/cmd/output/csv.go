@@ -26,6 +26,7 @@ import (
 	"io"
 
 	"github.com/Zaba505/tblconv"
+	"github.com/Zaba505/tblconv/cmd/tblconv/cmd/flags"
 
 	"github.com/spf13/cobra"
 )
@@ -34,7 +35,47 @@ func init() {
 	register(
 		"csv",
 		"Write data formatted as CSV.",
-		func(_ *cobra.Command) {},
-		func(w io.Writer, _ *cobra.Command) tblconv.Writer { return tblconv.NewCSVWriter(w) },
+		func(cmd *cobra.Command) {
+			cmd.Flags().Bool("header", true, "Write column names as the first row, when the source has them.")
+			flags.Rune(cmd.Flags(), "delimiter", ',', "Field delimiter. Use \"tab\" or \"\\t\" for TSV.")
+			cmd.Flags().Bool("crlf", false, "End lines with \\r\\n.")
+			cmd.Flags().Bool("always-quote", false, "Quote every field.")
+			cmd.Flags().String("encoding", "utf-8", "Character encoding (possible values: utf-8, utf-8-bom, utf-16le, utf-16be, latin1, windows-1252).")
+		},
+		func(w io.Writer, cmd *cobra.Command) tblconv.Writer {
+			header, err := cmd.Flags().GetBool("header")
+			if err != nil {
+				panic(err)
+			}
+			delim, err := flags.GetRune(cmd.Flags(), "delimiter")
+			if err != nil {
+				panic(err)
+			}
+			crlf, err := cmd.Flags().GetBool("crlf")
+			if err != nil {
+				panic(err)
+			}
+			alwaysQuote, err := cmd.Flags().GetBool("always-quote")
+			if err != nil {
+				panic(err)
+			}
+			encoding, err := cmd.Flags().GetString("encoding")
+			if err != nil {
+				panic(err)
+			}
+			enc, err := tblconv.ParseEncoding(encoding)
+			if err != nil {
+				panic(err)
+			}
+
+			return tblconv.NewCSVWriter(
+				w,
+				tblconv.CSVHeader(header),
+				tblconv.CSVDelimiter(delim),
+				tblconv.CSVUseCRLF(crlf),
+				tblconv.CSVAlwaysQuote(alwaysQuote),
+				tblconv.CSVEncoding(enc),
+			)
+		},
 	)
 }
@@ -23,11 +23,14 @@ THE SOFTWARE.
 package output
 
 import (
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/Zaba505/tblconv"
 
 	"github.com/spf13/cobra"
+	"github.com/xuri/excelize/v2"
 )
 
 func init() {
@@ -36,13 +39,89 @@ func init() {
 		"Write data formatted as an Excel spreadsheet.",
 		func(cmd *cobra.Command) {
 			cmd.Flags().StringP("sheet", "s", tblconv.DefaultSheetName, "Excel sheet name write data to.")
+			cmd.Flags().StringArray("format", []string{}, "Number format of a column, given as COLUMN=FORMAT, e.g. price=#,##0.00. May be repeated.")
+			cmd.Flags().String("date-format", "yyyy-mm-dd", "Number format of date cells.")
+			cmd.Flags().String("time-format", "yyyy-mm-dd hh:mm:ss", "Number format of timestamp cells.")
+			cmd.Flags().Bool("header-style", false, "Make the header row bold with a fill color.")
+			cmd.Flags().String("header-color", "D9D9D9", "Fill color of the header row as a hex RGB value, used with --header-style.")
+			cmd.Flags().Bool("auto-width", false, "Size columns to fit their contents.")
+			cmd.Flags().Bool("freeze-header", false, "Keep the header row visible when scrolling.")
+			cmd.Flags().Bool("autofilter", false, "Add an autofilter over the data.")
+			cmd.Flags().String("null-as", "", "Text of the cells written for NULL values (default empty cells).")
+			cmd.Flags().String("table", "", "Format the data as an Excel table with the given style, e.g. TableStyleMedium2.")
 		},
 		func(w io.Writer, cmd *cobra.Command) tblconv.Writer {
 			sheet, err := cmd.Flags().GetString("sheet")
 			if err != nil {
 				panic(err)
 			}
-			return tblconv.NewExcelWriter(w, tblconv.SheetName(sheet))
+			formats, err := cmd.Flags().GetStringArray("format")
+			if err != nil {
+				panic(err)
+			}
+			dateFormat, err := cmd.Flags().GetString("date-format")
+			if err != nil {
+				panic(err)
+			}
+			timeFormat, err := cmd.Flags().GetString("time-format")
+			if err != nil {
+				panic(err)
+			}
+
+			headerStyle, err := cmd.Flags().GetBool("header-style")
+			if err != nil {
+				panic(err)
+			}
+			headerColor, err := cmd.Flags().GetString("header-color")
+			if err != nil {
+				panic(err)
+			}
+			autoWidth, err := cmd.Flags().GetBool("auto-width")
+			if err != nil {
+				panic(err)
+			}
+			freezeHeader, err := cmd.Flags().GetBool("freeze-header")
+			if err != nil {
+				panic(err)
+			}
+			autoFilter, err := cmd.Flags().GetBool("autofilter")
+			if err != nil {
+				panic(err)
+			}
+			table, err := cmd.Flags().GetString("table")
+			if err != nil {
+				panic(err)
+			}
+			nullAs, err := cmd.Flags().GetString("null-as")
+			if err != nil {
+				panic(err)
+			}
+
+			opts := []tblconv.ExcelOption{
+				tblconv.SheetName(sheet),
+				tblconv.KindFormat(tblconv.DateKind, dateFormat),
+				tblconv.KindFormat(tblconv.TimeKind, timeFormat),
+				tblconv.AutoWidth(autoWidth),
+				tblconv.FreezeHeader(freezeHeader),
+				tblconv.AutoFilter(autoFilter),
+				tblconv.Table(table),
+				tblconv.NullCell(nullAs),
+			}
+			if headerStyle {
+				opts = append(opts, tblconv.HeaderStyle(&excelize.Style{
+					Font: &excelize.Font{Bold: true},
+					Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#" + strings.TrimPrefix(headerColor, "#")}},
+				}))
+			}
+			for _, format := range formats {
+				column, numFmt, ok := strings.Cut(format, "=")
+				if !ok {
+					panic(fmt.Errorf("expected COLUMN=FORMAT: %s", format))
+				}
+				opts = append(opts, tblconv.ColumnFormat(column, numFmt))
+			}
+
+			return tblconv.NewExcelWriter(w, opts...)
 		},
 	)
 }
@@ -23,18 +23,28 @@ package output
 
 import (
 	"database/sql"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/Zaba505/tblconv"
+	"github.com/Zaba505/tblconv/bulk"
 	"github.com/Zaba505/tblconv/sql/plugin"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	server string
-	query  string
-	dsn    string
+	server     string
+	query      string
+	dsn        string
+	savepoints bool
+	batchSize  int
+	prepared   bool
+	bulkLoad   bool
+	table      string
+	columns    []string
+	exact      bool
 )
 
 func init() {
@@ -54,22 +64,95 @@ func init() {
 			cmd.Flags().StringVarP(&server, "sql-server", "s", "", "SQL server (possible values: "+s+")")
 			cmd.Flags().StringVarP(&query, "query", "q", "", "SQL query for retrieving data")
 			cmd.Flags().StringVar(&dsn, "dsn", "", "Database endpoint")
+			cmd.Flags().BoolVar(&savepoints, "savepoints", false, "Write each record within its own savepoint so failed records can be skipped")
+			cmd.Flags().IntVar(&batchSize, "batch-size", 0, "Number of records to insert at once by rewriting --query, an INSERT ... VALUES (...), into a multi-row INSERT")
+			cmd.Flags().BoolVar(&prepared, "prepared", false, "Prepare --query once and reuse it for every record or batch")
+			cmd.Flags().BoolVar(&bulkLoad, "bulk", false, "Bulk load into --table with COPY (postgres) or LOAD DATA LOCAL INFILE (mysql) instead of running --query")
+			cmd.Flags().StringVar(&table, "table", "", "Table to insert data into with a generated INSERT, instead of running --query")
+			cmd.Flags().StringSliceVar(&columns, "columns", nil, "Columns of --table to insert data into, in order, or SRC=DST pairs mapping source columns onto columns of --table (default: the source header)")
+
+			cmd.Flags().BoolVar(&exact, "exact-identifiers", false, "Quote every identifier of --table as is, instead of leaving plain names for the database to fold to its case")
 
 			cmd.MarkFlagRequired("sql-server")
-			cmd.MarkFlagRequired("query")
 			cmd.MarkFlagRequired("dsn")
 		},
 		func(_ io.Writer, cmd *cobra.Command) tblconv.Writer {
+			if bulkLoad && table == "" {
+				panic(fmt.Errorf("--bulk requires --table"))
+			}
+			if query != "" && table != "" {
+				panic(fmt.Errorf("--query and --table cannot be used together"))
+			}
+			if query == "" && table == "" {
+				panic(fmt.Errorf("required flag \"query\" or \"table\" not set"))
+			}
+
 			db, err := openDB(server, dsn)
 			if err != nil {
 				panic(err)
 			}
 
-			return tblconv.NewSQLWriter(db, query)
+			targets, mappings := splitColumns(columns)
+			if bulkLoad {
+				if len(mappings) > 0 {
+					panic(fmt.Errorf("--bulk does not support mapping columns: %s", mappings[0]))
+				}
+				if exact {
+					panic(fmt.Errorf("--bulk does not support --exact-identifiers"))
+				}
+				return bulk.NewWriter(db, bulkMethod(server), table, targets...)
+			}
+
+			opts := []tblconv.SQLOption{
+				tblconv.Savepoints(savepoints),
+				tblconv.BatchSize(batchSize),
+				tblconv.Prepared(prepared),
+			}
+			if table != "" {
+				dialect, err := tblconv.ParseDialect(server)
+				if err != nil {
+					panic(err)
+				}
+				opts = append(opts, tblconv.InsertInto(table, dialect, targets...), tblconv.ExactIdentifiers(exact))
+
+				for _, mapping := range mappings {
+					src, dst, _ := strings.Cut(mapping, "=")
+					opts = append(opts, tblconv.MapColumn(src, dst))
+				}
+			}
+
+			return tblconv.NewSQLWriter(db, query, opts...)
 		},
 	)
 }
 
+// splitColumns splits --columns into the columns to insert into
+// and the SRC=DST pairs mapping source columns, which are exclusive.
+func splitColumns(columns []string) (targets, mappings []string) {
+	for _, col := range columns {
+		if strings.Contains(col, "=") {
+			mappings = append(mappings, col)
+			continue
+		}
+		targets = append(targets, col)
+	}
+	if len(targets) > 0 && len(mappings) > 0 {
+		panic(fmt.Errorf("--columns must either list columns or map them with SRC=DST: %s", strings.Join(columns, ",")))
+	}
+	return
+}
+
+func bulkMethod(server string) bulk.Method {
+	switch server {
+	case "postgres":
+		return bulk.PostgresCopy
+	case "mysql":
+		return bulk.MySQLLoadData
+	default:
+		panic(fmt.Errorf("--bulk is only supported for postgres and mysql: %s", server))
+	}
+}
+
 func openDB(name string, connStr string) (*sql.DB, error) {
 	if contains(sql.Drivers(), name) {
 		return sql.Open(name, connStr)
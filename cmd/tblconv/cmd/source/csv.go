@@ -26,6 +26,7 @@ import (
 	"io"
 
 	"github.com/Zaba505/tblconv"
+	"github.com/Zaba505/tblconv/cmd/tblconv/cmd/flags"
 
 	"github.com/spf13/cobra"
 )
@@ -34,7 +35,62 @@ func init() {
 	register(
 		"csv",
 		"Read data formatted as CSV.",
-		func(_ *cobra.Command) {},
-		func(r io.Reader, _ *cobra.Command) tblconv.Reader { return tblconv.NewCSVReader(r) },
+		func(cmd *cobra.Command) {
+			cmd.Flags().Bool("header", false, "Treat the first row as column names.")
+			flags.Rune(cmd.Flags(), "delimiter", ',', "Field delimiter. Use \"tab\" or \"\\t\" for TSV.")
+			flags.Rune(cmd.Flags(), "comment", 0, "Ignore lines beginning with this character.")
+			cmd.Flags().Bool("lazy-quotes", false, "Allow quotes in unquoted fields and non-doubled quotes in quoted fields.")
+			cmd.Flags().Bool("trim-leading-space", false, "Ignore leading white space in fields.")
+			cmd.Flags().Bool("variable-fields", false, "Allow records to have a variable number of fields.")
+			cmd.Flags().String("encoding", "auto", "Character encoding (possible values: auto, utf-8, utf-8-bom, utf-16le, utf-16be, latin1, windows-1252).")
+		},
+		func(r io.Reader, cmd *cobra.Command) tblconv.Reader {
+			header, err := cmd.Flags().GetBool("header")
+			if err != nil {
+				panic(err)
+			}
+			delim, err := flags.GetRune(cmd.Flags(), "delimiter")
+			if err != nil {
+				panic(err)
+			}
+			comment, err := flags.GetRune(cmd.Flags(), "comment")
+			if err != nil {
+				panic(err)
+			}
+			lazyQuotes, err := cmd.Flags().GetBool("lazy-quotes")
+			if err != nil {
+				panic(err)
+			}
+			trimLeadingSpace, err := cmd.Flags().GetBool("trim-leading-space")
+			if err != nil {
+				panic(err)
+			}
+			variableFields, err := cmd.Flags().GetBool("variable-fields")
+			if err != nil {
+				panic(err)
+			}
+			encoding, err := cmd.Flags().GetString("encoding")
+			if err != nil {
+				panic(err)
+			}
+			enc, err := tblconv.ParseEncoding(encoding)
+			if err != nil {
+				panic(err)
+			}
+
+			opts := []tblconv.CSVOption{
+				tblconv.CSVHeader(header),
+				tblconv.CSVDelimiter(delim),
+				tblconv.CSVComment(comment),
+				tblconv.CSVLazyQuotes(lazyQuotes),
+				tblconv.CSVTrimLeadingSpace(trimLeadingSpace),
+				tblconv.CSVEncoding(enc),
+			}
+			if variableFields {
+				opts = append(opts, tblconv.CSVFieldsPerRecord(-1))
+			}
+
+			return tblconv.NewCSVReader(r, opts...)
+		},
 	)
 }
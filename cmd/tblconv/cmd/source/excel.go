@@ -23,6 +23,7 @@ THE SOFTWARE.
 package source
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/Zaba505/tblconv"
@@ -33,16 +34,46 @@ import (
 func init() {
 	register(
 		"excel",
-		"Read data formatted as CSV.",
+		"Read data from an Excel (.xlsx) workbook.",
 		func(cmd *cobra.Command) {
-			cmd.Flags().StringP("sheet", "s", tblconv.DefaultSheetName, "Excel sheet name to read values from.")
+			sheetFlags(cmd, tblconv.DefaultSheetName)
+			cmd.Flags().String("defined-name", "", "Defined name of the cell range to read. Overrides --sheet and --range.")
+			cmd.Flags().String("formulas", "cached", "What to read from formula cells (possible values: cached, text, recalc).")
+			cmd.Flags().Bool("fill-merged", false, "Read the value of a merged cell from every cell it covers.")
 		},
 		func(r io.Reader, cmd *cobra.Command) tblconv.Reader {
-			sheet, err := cmd.Flags().GetString("sheet")
+			definedName, err := cmd.Flags().GetString("defined-name")
 			if err != nil {
 				panic(err)
 			}
-			return tblconv.NewExcelReader(r, tblconv.SheetName(sheet))
+			formulas, err := cmd.Flags().GetString("formulas")
+			if err != nil {
+				panic(err)
+			}
+			fillMerged, err := cmd.Flags().GetBool("fill-merged")
+			if err != nil {
+				panic(err)
+			}
+
+			var mode tblconv.FormulaMode
+			switch formulas {
+			case "cached":
+				mode = tblconv.CachedValue
+			case "text":
+				mode = tblconv.FormulaText
+			case "recalc":
+				mode = tblconv.Recalculate
+			default:
+				panic(fmt.Errorf("unknown --formulas value: %s", formulas))
+			}
+
+			opts := append(
+				sheetOptions(cmd),
+				tblconv.DefinedName(definedName),
+				tblconv.Formulas(mode),
+				tblconv.FillMergedCells(fillMerged),
+			)
+			return tblconv.NewExcelReader(r, opts...)
 		},
 	)
 }
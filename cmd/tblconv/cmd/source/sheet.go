@@ -0,0 +1,104 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package source
+
+import (
+	"github.com/Zaba505/tblconv"
+
+	"github.com/spf13/cobra"
+)
+
+// sheetFlags registers the flags shared by the spreadsheet sources.
+// Without a default sheet, the first sheet is read.
+func sheetFlags(cmd *cobra.Command, defaultSheet string) {
+	usage := "Sheet name to read values from."
+	if defaultSheet == "" {
+		usage = "Sheet name to read values from (default the first sheet)."
+	}
+
+	cmd.Flags().StringP("sheet", "s", defaultSheet, usage)
+	cmd.Flags().Int("sheet-index", 0, "Position of the sheet to read values from, counting from 1. Overrides --sheet.")
+	cmd.Flags().Bool("all-sheets", false, "Read every sheet in sequence. Same as --sheet-pattern='*'.")
+	cmd.Flags().String("sheet-pattern", "", "Read every sheet whose name matches a glob pattern, in sequence.")
+	cmd.Flags().String("sheet-column", "sheet", "Name of the column holding the sheet name of each row when reading multiple sheets (empty for none).")
+	cmd.Flags().String("range", "", "Cell range to read, e.g. B4:H200.")
+	cmd.Flags().Int("skip-rows", 0, "Number of leading rows to skip.")
+	cmd.Flags().Bool("stop-at-empty", false, "Stop reading a sheet at the first empty row.")
+	cmd.Flags().Int("header-row", 0, "Row number holding the column names, counting from the first row read (0 for none).")
+}
+
+// sheetOptions returns the options set by the flags of sheetFlags.
+func sheetOptions(cmd *cobra.Command) []tblconv.ExcelOption {
+	sheet, err := cmd.Flags().GetString("sheet")
+	if err != nil {
+		panic(err)
+	}
+	sheetIndex, err := cmd.Flags().GetInt("sheet-index")
+	if err != nil {
+		panic(err)
+	}
+	allSheets, err := cmd.Flags().GetBool("all-sheets")
+	if err != nil {
+		panic(err)
+	}
+	sheetPattern, err := cmd.Flags().GetString("sheet-pattern")
+	if err != nil {
+		panic(err)
+	}
+	sheetColumn, err := cmd.Flags().GetString("sheet-column")
+	if err != nil {
+		panic(err)
+	}
+	cellRange, err := cmd.Flags().GetString("range")
+	if err != nil {
+		panic(err)
+	}
+	skipRows, err := cmd.Flags().GetInt("skip-rows")
+	if err != nil {
+		panic(err)
+	}
+	stopAtEmpty, err := cmd.Flags().GetBool("stop-at-empty")
+	if err != nil {
+		panic(err)
+	}
+	headerRow, err := cmd.Flags().GetInt("header-row")
+	if err != nil {
+		panic(err)
+	}
+
+	opts := []tblconv.ExcelOption{
+		tblconv.SheetName(sheet),
+		tblconv.SheetIndex(sheetIndex),
+		tblconv.CellRange(cellRange),
+		tblconv.SkipRows(skipRows),
+		tblconv.StopAtEmptyRow(stopAtEmpty),
+		tblconv.HeaderRow(headerRow),
+	}
+	if allSheets {
+		sheetPattern = "*"
+	}
+	if sheetPattern != "" {
+		opts = append(opts, tblconv.SheetPattern(sheetPattern), tblconv.SheetColumn(sheetColumn))
+	}
+	return opts
+}
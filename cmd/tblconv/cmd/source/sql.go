@@ -24,7 +24,10 @@ package source
 
 import (
 	"database/sql"
+	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/Zaba505/tblconv"
 	"github.com/Zaba505/tblconv/sql/plugin"
@@ -37,6 +40,12 @@ var (
 	query  string
 	args   []string
 	dsn    string
+	nullAs string
+
+	timeFormat    string
+	dateFormat    string
+	binaryFormat  string
+	columnFormats []string
 )
 
 func init() {
@@ -57,23 +66,49 @@ func init() {
 			cmd.Flags().StringVarP(&query, "query", "q", "", "SQL query for retrieving data")
 			cmd.Flags().StringSliceVarP(&args, "arg", "a", []string{}, "Values for filling in query placeholder parameters")
 			cmd.Flags().StringVar(&dsn, "dsn", "", "Database endpoint")
+			cmd.Flags().StringVar(&nullAs, "null-as", "", "Field value for NULL when writing text outputs such as csv, e.g. NULL (default empty)")
+			cmd.Flags().StringVar(&timeFormat, "time-format", time.RFC3339Nano, "Go time layout of timestamps")
+			cmd.Flags().StringVar(&dateFormat, "date-format", "2006-01-02", "Go time layout of dates")
+			cmd.Flags().StringVar(&binaryFormat, "binary", "hex", "Format of binary values (possible values: hex, base64, raw)")
+			cmd.Flags().StringArrayVar(&columnFormats, "format", []string{}, "Format of a single column as COLUMN=FORMAT, where FORMAT is a Go time layout or binary format. May be repeated.")
 
 			cmd.MarkFlagRequired("sql-server")
 			cmd.MarkFlagRequired("query")
 			cmd.MarkFlagRequired("dsn")
 		},
 		func(_ io.Reader, cmd *cobra.Command) tblconv.Reader {
-			db, err := openDB(server, dsn)
+			db, err := OpenDB(server, dsn)
+			if err != nil {
+				panic(err)
+			}
+
+			binary, err := tblconv.ParseBinaryFormat(binaryFormat)
 			if err != nil {
 				panic(err)
 			}
 
-			return tblconv.NewSQLReader(db, query, interfaceSlicize(args)...)
+			opts := []tblconv.SQLReaderOption{
+				tblconv.NullString(nullAs),
+				tblconv.TimeFormat(timeFormat),
+				tblconv.DateFormat(dateFormat),
+				tblconv.Binary(binary),
+			}
+			for _, columnFormat := range columnFormats {
+				column, format, ok := strings.Cut(columnFormat, "=")
+				if !ok {
+					panic(fmt.Errorf("invalid --format, expected COLUMN=FORMAT: %s", columnFormat))
+				}
+				opts = append(opts, tblconv.FormatColumn(column, format))
+			}
+
+			return tblconv.NewSQLReaderWithOptions(db, query, interfaceSlicize(args), opts...)
 		},
 	)
 }
 
-func openDB(name string, connStr string) (*sql.DB, error) {
+// OpenDB opens a database with a built-in driver, or else with
+// the tblconv-plugin-<name> driver plugin.
+func OpenDB(name string, connStr string) (*sql.DB, error) {
 	if contains(sql.Drivers(), name) {
 		return sql.Open(name, connStr)
 	}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Zaba505/tblconv"
+
+	"github.com/spf13/cobra"
+)
+
+func addTransformFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("trim", false, "Trim leading and trailing white space from every field.")
+	cmd.Flags().String("where", "", "Only convert records matching an expression, e.g. \"age >= 21 and state in ('CA', 'NY')\".")
+	cmd.Flags().StringArray("rename", []string{}, "Rename a column, given as OLD=NEW. May be repeated.")
+	cmd.Flags().StringArray("add", []string{}, "Append a column with a constant value, given as NAME=VALUE. May be repeated.")
+	cmd.Flags().StringSlice("select", []string{}, "Columns to keep, in order, by name or position (counting from 1).")
+	cmd.Flags().StringSlice("drop", []string{}, "Columns to remove, by name or position (counting from 1).")
+}
+
+// transforms returns the transforms requested by flags, in the order:
+// trim, where, rename, add, select, drop. null is the field read
+// by the source for NULL values, if any.
+func transforms(cmd *cobra.Command, null string) ([]tblconv.Transform, error) {
+	var ts []tblconv.Transform
+
+	trim, err := cmd.Flags().GetBool("trim")
+	if err != nil {
+		return nil, err
+	}
+	if trim {
+		ts = append(ts, tblconv.TrimSpace())
+	}
+
+	where, err := cmd.Flags().GetString("where")
+	if err != nil {
+		return nil, err
+	}
+	if where != "" {
+		expr, err := tblconv.ParseExpr(where, tblconv.NullField(null))
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, tblconv.Where(expr))
+	}
+
+	renames, err := cmd.Flags().GetStringArray("rename")
+	if err != nil {
+		return nil, err
+	}
+	for _, rename := range renames {
+		from, to, err := splitPair(rename)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, tblconv.Rename(from, to))
+	}
+
+	adds, err := cmd.Flags().GetStringArray("add")
+	if err != nil {
+		return nil, err
+	}
+	for _, add := range adds {
+		name, value, err := splitPair(add)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, tblconv.AddColumn(name, value))
+	}
+
+	selected, err := cmd.Flags().GetStringSlice("select")
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) > 0 {
+		ts = append(ts, tblconv.Select(selected...))
+	}
+
+	dropped, err := cmd.Flags().GetStringSlice("drop")
+	if err != nil {
+		return nil, err
+	}
+	if len(dropped) > 0 {
+		ts = append(ts, tblconv.Drop(dropped...))
+	}
+
+	return ts, nil
+}
+
+func splitPair(s string) (string, string, error) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return "", "", fmt.Errorf("expected KEY=VALUE: %s", s)
+	}
+	return s[:i], s[i+1:], nil
+}
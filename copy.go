@@ -0,0 +1,478 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type copyConfig struct {
+	bufferSize       int
+	stats            *Stats
+	progress         func(Stats)
+	progressInterval time.Duration
+	skipErrors       bool
+	maxErrors        int64
+	reject           Writer
+}
+
+// CopyOption
+type CopyOption func(*copyConfig)
+
+// Pipeline runs the reader and writer in separate goroutines, connected
+// by a channel which buffers up to bufferSize records, so that a slow
+// writer does not stall the reader and vice versa. A bufferSize of 0,
+// the default, reads and writes each record in turn.
+//
+// Readers must not reuse the slices they return when pipelined.
+//
+func Pipeline(bufferSize int) CopyOption {
+	return func(cfg *copyConfig) {
+		cfg.bufferSize = bufferSize
+	}
+}
+
+// Stats summarizes the progress of a copy.
+type Stats struct {
+	RecordsRead    int64
+	RecordsWritten int64
+	RecordsSkipped int64
+
+	// Bytes is the approximate size of the records written. Strings
+	// count their length and other typed values the size of their
+	// native representation.
+	Bytes int64
+
+	Elapsed time.Duration
+
+	// FailedRecord is the index, counting from 0, of the record which
+	// caused the copy to fail. It is -1 if no record failed.
+	FailedRecord int64
+}
+
+// WithStats stores the statistics of the copy in stats once it completes,
+// whether it succeeded or not.
+func WithStats(stats *Stats) CopyOption {
+	return func(cfg *copyConfig) {
+		cfg.stats = stats
+	}
+}
+
+// Progress calls fn with the current statistics of the copy every interval,
+// and once more when the copy completes. fn is called from a separate
+// goroutine but never concurrently.
+func Progress(interval time.Duration, fn func(Stats)) CopyOption {
+	return func(cfg *copyConfig) {
+		cfg.progress = fn
+		cfg.progressInterval = interval
+	}
+}
+
+// SkipErrors skips records which fail to be read or written instead of
+// abandoning the copy, until more than maxErrors records have failed.
+// A negative maxErrors allows any number of failures.
+//
+// A read error only causes the record to be skipped if it is a
+// *RecordError or *csv.ParseError, since any other error can not be
+// attributed to a single record. Write errors always cause the
+// record to be skipped, unless the context of the copy is done or
+// the error is not confined to the record, e.g. a failed batch of
+// a SQLWriter, see BatchSize.
+//
+func SkipErrors(maxErrors int) CopyOption {
+	return func(cfg *copyConfig) {
+		cfg.skipErrors = true
+		cfg.maxErrors = int64(maxErrors)
+	}
+}
+
+// RejectTo writes every skipped record to w, with the error message
+// appended as its last field. w is flushed when the copy completes.
+// See SkipErrors.
+func RejectTo(w Writer) CopyOption {
+	return func(cfg *copyConfig) {
+		cfg.reject = w
+	}
+}
+
+// RecordError reports a failure confined to a single record, after
+// which reading or writing can continue with the next record.
+type RecordError struct {
+	// Record holds the fields of the failed record, if available.
+	Record []string
+	Err    error
+}
+
+// Error
+func (e *RecordError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+func newCopyConfig(opts []CopyOption) copyConfig {
+	var cfg copyConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+type copier[T any] struct {
+	// accessed atomically, so they're kept first for 64-bit alignment
+	read    int64
+	written int64
+	skipped int64
+	bytes   int64
+	failed  int64
+
+	cfg   copyConfig
+	start time.Time
+	pos   int64
+
+	rejectMu sync.Mutex
+
+	readRecord  func(context.Context) ([]T, error)
+	writeRecord func(context.Context, []T) error
+	size        func([]T) int
+	strings     func([]T) []string
+}
+
+func newCopier[T any](cfg copyConfig, read func(context.Context) ([]T, error), write func(context.Context, []T) error, size func([]T) int, strings func([]T) []string) *copier[T] {
+	return &copier[T]{
+		failed:      -1,
+		cfg:         cfg,
+		readRecord:  read,
+		writeRecord: write,
+		size:        size,
+		strings:     strings,
+	}
+}
+
+func (c *copier[T]) run(ctx context.Context, w, r interface{}) error {
+	c.start = time.Now()
+
+	stop := c.reportProgress()
+	err := c.copy(ctx, w, r)
+	stop()
+
+	if f, ok := c.cfg.reject.(Flusher); ok {
+		ferr := f.Flush()
+		if err == nil {
+			err = ferr
+		}
+	}
+
+	stats := c.stats()
+	if c.cfg.stats != nil {
+		*c.cfg.stats = stats
+	}
+	if c.cfg.progress != nil {
+		c.cfg.progress(stats)
+	}
+	return err
+}
+
+func (c *copier[T]) copy(ctx context.Context, w, r interface{}) error {
+	err := copySchema(ctx, w, r)
+	if err == nil {
+		if c.cfg.bufferSize > 0 {
+			err = c.pipeline(ctx)
+		} else {
+			err = c.readAll(ctx, c.put)
+		}
+	}
+	if err != nil {
+		abort(r)
+		abort(w)
+		return err
+	}
+
+	if f, ok := w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (c *copier[T]) stats() Stats {
+	return Stats{
+		RecordsRead:    atomic.LoadInt64(&c.read),
+		RecordsWritten: atomic.LoadInt64(&c.written),
+		RecordsSkipped: atomic.LoadInt64(&c.skipped),
+		Bytes:          atomic.LoadInt64(&c.bytes),
+		Elapsed:        time.Since(c.start),
+		FailedRecord:   atomic.LoadInt64(&c.failed),
+	}
+}
+
+func (c *copier[T]) reportProgress() (stop func()) {
+	if c.cfg.progress == nil || c.cfg.progressInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(c.cfg.progressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.cfg.progress(c.stats())
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// readAll reads records until EOF, handing each one to fn along
+// with its index.
+func (c *copier[T]) readAll(ctx context.Context, fn func(context.Context, int64, []T) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		idx := c.pos
+		record, err := c.readRecord(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		c.pos += 1
+
+		var recErr *RecordError
+		var parseErr *csv.ParseError
+		switch {
+		case err == nil:
+		case errors.As(err, &recErr):
+			err = c.skip(idx, recErr.Record, err)
+			if err != nil {
+				return err
+			}
+			continue
+		case errors.As(err, &parseErr):
+			err = c.skip(idx, nil, err)
+			if err != nil {
+				return err
+			}
+			continue
+		default:
+			c.fail(idx)
+			return err
+		}
+		atomic.AddInt64(&c.read, 1)
+
+		err = fn(ctx, idx, record)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *copier[T]) put(ctx context.Context, idx int64, record []T) error {
+	err := c.writeRecord(ctx, record)
+	var batchErr *batchError
+	if err != nil && (ctx.Err() != nil || errors.As(err, &batchErr)) {
+		c.fail(idx)
+		return err
+	}
+	if err != nil {
+		return c.skip(idx, c.strings(record), err)
+	}
+
+	atomic.AddInt64(&c.written, 1)
+	atomic.AddInt64(&c.bytes, int64(c.size(record)))
+	return nil
+}
+
+// skip records the failure of a record, returning an error if
+// the failure can't be skipped.
+func (c *copier[T]) skip(idx int64, record []string, err error) error {
+	if !c.cfg.skipErrors {
+		c.fail(idx)
+		return err
+	}
+
+	skipped := atomic.AddInt64(&c.skipped, 1)
+	if c.cfg.maxErrors >= 0 && skipped > c.cfg.maxErrors {
+		c.fail(idx)
+		return fmt.Errorf("tblconv: more than %d records failed: %w", c.cfg.maxErrors, err)
+	}
+
+	if c.cfg.reject == nil {
+		return nil
+	}
+
+	c.rejectMu.Lock()
+	defer c.rejectMu.Unlock()
+
+	rejected := make([]string, len(record), len(record)+1)
+	copy(rejected, record)
+	return c.cfg.reject.Write(append(rejected, err.Error()))
+}
+
+func (c *copier[T]) fail(idx int64) {
+	atomic.CompareAndSwapInt64(&c.failed, -1, idx)
+}
+
+type indexedRecord[T any] struct {
+	idx    int64
+	record []T
+}
+
+// pipeline is the concurrent equivalent of readAll(ctx, c.put). The
+// first error encountered by either the reader or writer is returned.
+func (c *copier[T]) pipeline(ctx context.Context) error {
+	// only the reader is given the cancelable context since writers,
+	// such as SQLWriter, may bind state to the context which must
+	// outlive this function
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	records := make(chan indexedRecord[T], c.cfg.bufferSize)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(records)
+
+		readErr <- c.readAll(rctx, func(ctx context.Context, idx int64, record []T) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case records <- indexedRecord[T]{idx: idx, record: record}:
+				return nil
+			}
+		})
+	}()
+
+	for r := range records {
+		err := ctx.Err()
+		if err == nil {
+			err = c.put(ctx, r.idx, r.record)
+		}
+		if err != nil {
+			cancel()
+			for range records {
+			}
+			return err
+		}
+	}
+	return <-readErr
+}
+
+func copySchema(ctx context.Context, w, r interface{}) error {
+	sw, ok := w.(SchemaWriter)
+	if !ok {
+		return nil
+	}
+
+	columns, err := readSchema(ctx, r)
+	if err == ErrNoSchema || err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return sw.WriteSchema(columns)
+}
+
+func abort(v interface{}) {
+	if a, ok := v.(Aborter); ok {
+		a.Abort()
+	}
+}
+
+func readFunc(r Reader) func(context.Context) ([]string, error) {
+	if rc, ok := r.(ReaderContext); ok {
+		return rc.ReadContext
+	}
+	return func(context.Context) ([]string, error) { return r.Read() }
+}
+
+func writeFunc(w Writer) func(context.Context, []string) error {
+	if wc, ok := w.(WriterContext); ok {
+		return wc.WriteContext
+	}
+	return func(_ context.Context, record []string) error { return w.Write(record) }
+}
+
+func typedReadFunc(r TypedReader) func(context.Context) ([]Value, error) {
+	if rc, ok := r.(TypedReaderContext); ok {
+		return rc.ReadTypedContext
+	}
+	return func(context.Context) ([]Value, error) { return r.ReadTyped() }
+}
+
+func typedWriteFunc(w TypedWriter) func(context.Context, []Value) error {
+	if wc, ok := w.(TypedWriterContext); ok {
+		return wc.WriteTypedContext
+	}
+	return func(_ context.Context, record []Value) error { return w.WriteTyped(record) }
+}
+
+func recordStrings(record []string) []string {
+	return record
+}
+
+func recordSize(record []string) (n int) {
+	for _, field := range record {
+		n += len(field)
+	}
+	return
+}
+
+func typedRecordSize(record []Value) (n int) {
+	for _, val := range record {
+		switch x := val.V.(type) {
+		case string:
+			n += len(x)
+		case []byte:
+			n += len(x)
+		case bool:
+			n += 1
+		case nil:
+		default:
+			n += 8
+		}
+	}
+	return
+}
@@ -23,32 +23,251 @@ THE SOFTWARE.
 package tblconv
 
 import (
+	"bufio"
 	"encoding/csv"
 	"io"
+	"strings"
 )
 
+type csvConfig struct {
+	header           bool
+	comma            rune
+	comment          rune
+	lazyQuotes       bool
+	trimLeadingSpace bool
+	fieldsPerRecord  int
+	useCRLF          bool
+	alwaysQuote      bool
+	encoding         Encoding
+}
+
+// CSVOption
+type CSVOption func(*csvConfig)
+
+// CSVHeader configures whether CSV data starts with a header row of
+// column names.
+//
+// A CSVReader with a header exposes it through Schema instead of
+// returning it from Read. By default, CSVReader treats the first
+// row as data.
+//
+// A CSVWriter with a header writes the schema given to WriteSchema as
+// the first row. By default, CSVWriter writes a header whenever
+// a schema is available.
+//
+func CSVHeader(header bool) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.header = header
+	}
+}
+
+// CSVDelimiter sets the field delimiter for reading and writing.
+// It defaults to ',', use '\t' for TSV.
+func CSVDelimiter(delim rune) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.comma = delim
+	}
+}
+
+// CSVComment sets the comment character. Lines beginning with it
+// are ignored by CSVReader. It's disabled by default.
+func CSVComment(comment rune) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.comment = comment
+	}
+}
+
+// CSVLazyQuotes allows CSVReader to read quotes appearing in
+// unquoted fields and non-doubled quotes in quoted fields.
+func CSVLazyQuotes(lazy bool) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.lazyQuotes = lazy
+	}
+}
+
+// CSVTrimLeadingSpace configures CSVReader to ignore leading
+// white space in fields.
+func CSVTrimLeadingSpace(trim bool) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.trimLeadingSpace = trim
+	}
+}
+
+// CSVFieldsPerRecord sets the number of fields CSVReader expects in
+// every record. If n is 0, every record must have as many fields as the
+// first one. If n is negative, records may have a variable number of fields.
+func CSVFieldsPerRecord(n int) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.fieldsPerRecord = n
+	}
+}
+
+// CSVUseCRLF configures CSVWriter to end lines with \r\n instead of \n.
+func CSVUseCRLF(crlf bool) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.useCRLF = crlf
+	}
+}
+
+// CSVAlwaysQuote configures CSVWriter to quote every field, instead
+// of only those which require it.
+func CSVAlwaysQuote(quote bool) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.alwaysQuote = quote
+	}
+}
+
+// CSVEncoding sets the character encoding of CSV data. CSVReader decodes
+// it to UTF-8 and drops any byte order mark, detecting the encoding
+// by default. CSVWriter encodes UTF-8 to it and writes UTF-8 by default.
+func CSVEncoding(enc Encoding) CSVOption {
+	return func(cfg *csvConfig) {
+		cfg.encoding = enc
+	}
+}
+
+// CSVReader
+type CSVReader struct {
+	CSV *csv.Reader
+
+	cfg    csvConfig
+	header []string
+}
+
 // NewCSVReader
-func NewCSVReader(r io.Reader) *csv.Reader {
-	return csv.NewReader(r)
+func NewCSVReader(r io.Reader, opts ...CSVOption) *CSVReader {
+	cfg := csvConfig{
+		comma: ',',
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cr := csv.NewReader(decodeReader(r, cfg.encoding))
+	cr.Comma = cfg.comma
+	cr.Comment = cfg.comment
+	cr.LazyQuotes = cfg.lazyQuotes
+	cr.TrimLeadingSpace = cfg.trimLeadingSpace
+	cr.FieldsPerRecord = cfg.fieldsPerRecord
+
+	return &CSVReader{
+		CSV: cr,
+		cfg: cfg,
+	}
+}
+
+// Read
+func (r *CSVReader) Read() ([]string, error) {
+	if r.cfg.header && r.header == nil {
+		_, err := r.Schema()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := r.CSV.Read()
+	if _, ok := err.(*csv.ParseError); ok {
+		return nil, &RecordError{Record: record, Err: err}
+	}
+	return record, err
+}
+
+// Schema returns the header row as untyped columns. ErrNoSchema is
+// returned if the reader was not configured with CSVHeader.
+func (r *CSVReader) Schema() ([]Column, error) {
+	if !r.cfg.header {
+		return nil, ErrNoSchema
+	}
+
+	if r.header == nil {
+		header, err := r.CSV.Read()
+		if err != nil {
+			return nil, err
+		}
+		r.header = header
+	}
+
+	return columnsNamed(r.header), nil
 }
 
 // CSVWriter
 type CSVWriter struct {
 	CSV *csv.Writer
+
+	cfg csvConfig
+	buf *bufio.Writer
 }
 
 // NewCSVWriter
-func NewCSVWriter(w io.Writer) *CSVWriter {
+func NewCSVWriter(w io.Writer, opts ...CSVOption) *CSVWriter {
+	cfg := csvConfig{
+		header: true,
+		comma:  ',',
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// csv.Writer reuses buf instead of wrapping it again, so records
+	// written by writeQuoted and CSV share the same buffer.
+	buf := bufio.NewWriter(encodeWriter(w, cfg.encoding))
+	cw := csv.NewWriter(buf)
+	cw.Comma = cfg.comma
+	cw.UseCRLF = cfg.useCRLF
+
 	return &CSVWriter{
-		CSV: csv.NewWriter(w),
+		CSV: cw,
+		cfg: cfg,
+		buf: buf,
+	}
+}
+
+// WriteSchema writes the column names as a header row.
+func (w *CSVWriter) WriteSchema(columns []Column) error {
+	if !w.cfg.header {
+		return nil
 	}
+	return w.Write(columnNames(columns))
 }
 
 // Write
 func (w *CSVWriter) Write(record []string) error {
+	if w.cfg.alwaysQuote {
+		return w.writeQuoted(record)
+	}
 	return w.CSV.Write(record)
 }
 
+func (w *CSVWriter) writeQuoted(record []string) error {
+	for i, field := range record {
+		if i > 0 {
+			_, err := w.buf.WriteRune(w.CSV.Comma)
+			if err != nil {
+				return err
+			}
+		}
+
+		field = strings.ReplaceAll(field, `"`, `""`)
+		if w.CSV.UseCRLF {
+			field = strings.ReplaceAll(strings.ReplaceAll(field, "\r\n", "\n"), "\n", "\r\n")
+		}
+
+		_, err := w.buf.WriteString(`"` + field + `"`)
+		if err != nil {
+			return err
+		}
+	}
+
+	eol := "\n"
+	if w.CSV.UseCRLF {
+		eol = "\r\n"
+	}
+	_, err := w.buf.WriteString(eol)
+	return err
+}
+
 // Flush
 func (w *CSVWriter) Flush() error {
 	w.CSV.Flush()
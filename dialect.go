@@ -0,0 +1,431 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// placeholderStyle is the syntax of query parameters of a database driver.
+type placeholderStyle uint8
+
+const (
+	// questionPlaceholder is positional, e.g. "?, ?", as used by MySQL and Snowflake.
+	questionPlaceholder placeholderStyle = iota
+
+	// dollarPlaceholder is numbered, e.g. "$1, $2", as used by Postgres.
+	dollarPlaceholder
+
+	// colonPlaceholder is numbered, e.g. ":1, :2", as used by Oracle.
+	colonPlaceholder
+)
+
+// placeholder is a query parameter found at query[start:end].
+type placeholder struct {
+	start, end int
+	style      placeholderStyle
+
+	// index of a numbered placeholder, counting from 1
+	index int
+}
+
+// codeMask reports for each byte of query whether it is SQL code, as
+// opposed to part of a string literal, quoted identifier or comment.
+func codeMask(query string) []bool {
+	mask := make([]bool, len(query))
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			// a doubled quote is an escaped quote within the literal
+			for i++; i < len(query); i++ {
+				if query[i] != c {
+					continue
+				}
+				if i+1 < len(query) && query[i+1] == c {
+					i++
+					continue
+				}
+				break
+			}
+		case strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+				break
+			}
+			i += 2 + end + 1
+		default:
+			mask[i] = true
+		}
+	}
+	return mask
+}
+
+// findPlaceholders returns the parameters within query[from:to].
+func findPlaceholders(query string, mask []bool, from, to int) ([]placeholder, error) {
+	var found []placeholder
+	for i := from; i < to; i++ {
+		if !mask[i] {
+			continue
+		}
+
+		p := placeholder{start: i}
+		switch query[i] {
+		case '?':
+			p.style = questionPlaceholder
+			p.end = i + 1
+		case '$', ':':
+			// leave out casts, e.g. "x::int", and names, e.g. ":name"
+			if query[i] == ':' && ((i > 0 && query[i-1] == ':') || (i+1 < to && query[i+1] == ':')) {
+				i++
+				continue
+			}
+			j := i + 1
+			for j < to && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j == i+1 {
+				continue
+			}
+
+			p.style = dollarPlaceholder
+			if query[i] == ':' {
+				p.style = colonPlaceholder
+			}
+			p.end = j
+			p.index, _ = strconv.Atoi(query[i+1 : j])
+		default:
+			continue
+		}
+
+		if len(found) > 0 && found[0].style != p.style {
+			return nil, errors.New("tblconv: query mixes placeholder styles")
+		}
+		found = append(found, p)
+		i = p.end - 1
+	}
+	return found, nil
+}
+
+// batchQuery is an INSERT ... VALUES (...) query which
+// is rewritten to insert several rows at once.
+type batchQuery struct {
+	prefix string
+	row    string
+	suffix string
+
+	// placeholders of row, relative to its start
+	placeholders []placeholder
+
+	// params is the number of parameters per row
+	params int
+}
+
+// parseBatchQuery parses an INSERT query with a single VALUES row,
+// e.g. "INSERT INTO t (a, b) VALUES ($1, $2) ON CONFLICT DO NOTHING".
+func parseBatchQuery(query string) (*batchQuery, error) {
+	mask := codeMask(query)
+	upper := strings.ToUpper(query)
+
+	start := -1
+	for i := strings.Index(upper, "VALUES"); i >= 0; {
+		end := i + len("VALUES")
+		if mask[i] && (i == 0 || !isWordByte(upper[i-1])) && (end == len(upper) || !isWordByte(upper[end])) {
+			start = end
+			break
+		}
+
+		next := strings.Index(upper[end:], "VALUES")
+		if next < 0 {
+			break
+		}
+		i = end + next
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("tblconv: batching requires an INSERT ... VALUES (...) query: %s", query)
+	}
+
+	for start < len(query) && (query[start] == ' ' || query[start] == '\t' || query[start] == '\n' || query[start] == '\r') {
+		start++
+	}
+	if start == len(query) || query[start] != '(' {
+		return nil, fmt.Errorf("tblconv: batching requires an INSERT ... VALUES (...) query: %s", query)
+	}
+
+	end, depth := start, 0
+	for ; end < len(query); end++ {
+		if !mask[end] {
+			continue
+		}
+		if query[end] == '(' {
+			depth++
+		}
+		if query[end] == ')' {
+			depth--
+			if depth == 0 {
+				break
+			}
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("tblconv: unbalanced parentheses in query: %s", query)
+	}
+	end++
+
+	holders, err := findPlaceholders(query, mask, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(holders) == 0 {
+		return nil, fmt.Errorf("tblconv: batched query has no placeholders: %s", query)
+	}
+
+	q := &batchQuery{
+		prefix: query[:start],
+		row:    query[start:end],
+		suffix: query[end:],
+	}
+	for _, p := range holders {
+		p.start -= start
+		p.end -= start
+		q.placeholders = append(q.placeholders, p)
+
+		switch {
+		case p.style == questionPlaceholder:
+			q.params++
+		case p.index > q.params:
+			q.params = p.index
+		}
+	}
+	return q, nil
+}
+
+// build returns the query for inserting n rows at once. Numbered
+// placeholders are renumbered for every row after the first.
+func (q *batchQuery) build(n int) string {
+	var sb strings.Builder
+	sb.WriteString(q.prefix)
+	for row := 0; row < n; row++ {
+		if row > 0 {
+			sb.WriteString(", ")
+		}
+
+		last := 0
+		for _, p := range q.placeholders {
+			sb.WriteString(q.row[last:p.start])
+			switch p.style {
+			case dollarPlaceholder:
+				sb.WriteString("$" + strconv.Itoa(row*q.params+p.index))
+			case colonPlaceholder:
+				sb.WriteString(":" + strconv.Itoa(row*q.params+p.index))
+			default:
+				sb.WriteString("?")
+			}
+			last = p.end
+		}
+		sb.WriteString(q.row[last:])
+	}
+	sb.WriteString(q.suffix)
+	return sb.String()
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// Dialect is the SQL dialect of a database, which determines how a
+// SQLWriter inserting into a table quotes identifiers and query parameters.
+// Identifiers are only quoted when they contain characters other than
+// letters, digits and underscores, start with a digit, or are reserved.
+type Dialect uint8
+
+const (
+	// PostgresDialect quotes identifiers with double quotes and
+	// numbers parameters, e.g. "$1, $2".
+	PostgresDialect Dialect = iota
+
+	// MySQLDialect quotes identifiers with backticks and
+	// uses positional parameters, e.g. "?, ?".
+	MySQLDialect
+
+	// SnowflakeDialect quotes identifiers with double quotes and
+	// uses positional parameters, e.g. "?, ?".
+	SnowflakeDialect
+
+	// GenericDialect quotes identifiers with double quotes and
+	// uses positional parameters, e.g. "?, ?", which most databases
+	// accept, e.g. SQLite.
+	GenericDialect
+)
+
+var dialectNames = []string{"postgres", "mysql", "snowflake", "generic"}
+
+// ParseDialect parses the name of a Dialect, which is the name
+// of its database driver, i.e. postgres, mysql or snowflake.
+// Any other driver, e.g. sqlite, is parsed as GenericDialect.
+func ParseDialect(s string) (Dialect, error) {
+	if s == "" {
+		return 0, errors.New("tblconv: empty sql dialect")
+	}
+
+	for i, name := range dialectNames {
+		if strings.EqualFold(s, name) {
+			return Dialect(i), nil
+		}
+	}
+	return GenericDialect, nil
+}
+
+// String
+func (d Dialect) String() string {
+	if int(d) < len(dialectNames) {
+		return dialectNames[d]
+	}
+	return "dialect(" + strconv.Itoa(int(d)) + ")"
+}
+
+// reservedWords are the keywords reserved by at least one dialect,
+// which must be quoted to be used as identifiers.
+var reservedWords = map[string]bool{
+	"all": true, "and": true, "any": true, "as": true, "asc": true,
+	"between": true, "by": true, "case": true, "check": true, "column": true,
+	"constraint": true, "create": true, "cross": true, "current_date": true,
+	"current_time": true, "current_timestamp": true, "current_user": true,
+	"default": true, "delete": true, "desc": true, "distinct": true, "drop": true,
+	"else": true, "end": true, "exists": true, "false": true, "fetch": true,
+	"for": true, "foreign": true, "from": true, "full": true, "grant": true,
+	"group": true, "having": true, "in": true, "inner": true, "insert": true,
+	"intersect": true, "into": true, "is": true, "join": true, "key": true,
+	"left": true, "like": true, "limit": true, "not": true, "null": true,
+	"of": true, "on": true, "or": true, "order": true, "outer": true,
+	"primary": true, "references": true, "right": true, "select": true,
+	"set": true, "table": true, "then": true, "to": true, "true": true,
+	"union": true, "unique": true, "update": true, "user": true, "using": true,
+	"values": true, "when": true, "where": true, "with": true,
+}
+
+// QuoteIdentifier quotes an identifier, unless it is a plain identifier,
+// which is left as is for the database to fold to its own case, e.g.
+// UserID is resolved as userid by Postgres and as USERID by Snowflake.
+// A plain identifier which is a reserved word is folded and quoted instead.
+func (d Dialect) QuoteIdentifier(ident string) string {
+	if isPlainIdent(ident) {
+		if !reservedWords[strings.ToLower(ident)] {
+			return ident
+		}
+		ident = d.fold(ident)
+	}
+	return d.quote(ident)
+}
+
+// quote quotes an identifier as is, so it only resolves to
+// the column whose name matches it exactly.
+func (d Dialect) quote(ident string) string {
+	if d == MySQLDialect {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// fold folds an unquoted identifier to the case the database stores it in.
+func (d Dialect) fold(ident string) string {
+	switch d {
+	case PostgresDialect:
+		return strings.ToLower(ident)
+	case SnowflakeDialect:
+		return strings.ToUpper(ident)
+	default:
+		return ident
+	}
+}
+
+// isPlainIdent reports whether s can be used as an identifier
+// without quoting it, unless it is a reserved word.
+func isPlainIdent(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isWordByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// QuoteTable quotes the name of a table with QuoteIdentifier. The name
+// may be qualified, e.g. "public.order" is quoted as public."order".
+func (d Dialect) QuoteTable(table string) string {
+	return quoteTable(table, d.QuoteIdentifier)
+}
+
+func quoteTable(table string, quote func(string) string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// placeholder returns the nth query parameter, counting from 1.
+func (d Dialect) placeholder(n int) string {
+	if d == PostgresDialect {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// insertStatement returns the query inserting a single row into the
+// given columns of table, whose identifiers are quoted as is if exact.
+func (d Dialect) insertStatement(table string, columns []string, exact bool) string {
+	quote := d.QuoteIdentifier
+	if exact {
+		quote = d.quote
+	}
+
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO " + quoteTable(table, quote) + " (")
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(quote(col))
+	}
+
+	sb.WriteString(") VALUES (")
+	for i := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(d.placeholder(i + 1))
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import "testing"
+
+func TestBatchQuery(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Query    string
+		Expected string
+		Params   int
+		Err      bool
+	}{
+		{
+			Name:     "Question",
+			Query:    "INSERT INTO t (a, b) VALUES (?, ?)",
+			Expected: "INSERT INTO t (a, b) VALUES (?, ?), (?, ?)",
+			Params:   2,
+		},
+		{
+			Name:     "Dollar",
+			Query:    "insert into t values ($1, lower($2), $1) on conflict do nothing",
+			Expected: "insert into t values ($1, lower($2), $1), ($3, lower($4), $3) on conflict do nothing",
+			Params:   2,
+		},
+		{
+			Name:     "Colon",
+			Query:    "INSERT INTO t VALUES(:1, :2::int)",
+			Expected: "INSERT INTO t VALUES(:1, :2::int), (:3, :4::int)",
+			Params:   2,
+		},
+		{
+			Name:     "QuotedAndCommented",
+			Query:    "INSERT INTO \"values\" VALUES ('?', ?, 'it''s $1') -- ?",
+			Expected: "INSERT INTO \"values\" VALUES ('?', ?, 'it''s $1'), ('?', ?, 'it''s $1') -- ?",
+			Params:   1,
+		},
+		{
+			Name:  "NoValues",
+			Query: "UPDATE t SET a = ?",
+			Err:   true,
+		},
+		{
+			Name:  "NoPlaceholders",
+			Query: "INSERT INTO t VALUES (1, 2)",
+			Err:   true,
+		},
+		{
+			Name:  "MixedPlaceholders",
+			Query: "INSERT INTO t VALUES (?, $2)",
+			Err:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			q, err := parseBatchQuery(testCase.Query)
+			if testCase.Err {
+				if err == nil {
+					subT.Logf("expected an error for query: %s", testCase.Query)
+					subT.Fail()
+				}
+				return
+			}
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if q.params != testCase.Params {
+				subT.Logf("expected %d parameters but got %d", testCase.Params, q.params)
+				subT.Fail()
+			}
+			if actual := q.build(2); actual != testCase.Expected {
+				subT.Logf("expected: %s\ngot: %s", testCase.Expected, actual)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Expected Dialect
+	}{
+		{Name: "postgres", Expected: PostgresDialect},
+		{Name: "MySQL", Expected: MySQLDialect},
+		{Name: "snowflake", Expected: SnowflakeDialect},
+		{Name: "sqlite", Expected: GenericDialect},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			d, err := ParseDialect(testCase.Name)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if d != testCase.Expected {
+				subT.Logf("expected: %s\ngot: %s", testCase.Expected, d)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestDialectQuoteIdentifier(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Dialect  Dialect
+		Ident    string
+		Expected string
+	}{
+		{Name: "PostgresMixedCase", Dialect: PostgresDialect, Ident: "UserId", Expected: `UserId`},
+		{Name: "PostgresReservedWord", Dialect: PostgresDialect, Ident: "Order", Expected: `"order"`},
+		{Name: "PostgresSpaces", Dialect: PostgresDialect, Ident: "Full Name", Expected: `"Full Name"`},
+		{Name: "PostgresQuote", Dialect: PostgresDialect, Ident: `say "hi"`, Expected: `"say ""hi"""`},
+		{Name: "SnowflakeMixedCase", Dialect: SnowflakeDialect, Ident: "UserId", Expected: `UserId`},
+		{Name: "SnowflakeReservedWord", Dialect: SnowflakeDialect, Ident: "order", Expected: `"ORDER"`},
+		{Name: "SnowflakeSpaces", Dialect: SnowflakeDialect, Ident: "full name", Expected: `"full name"`},
+		{Name: "MySQLReservedWord", Dialect: MySQLDialect, Ident: "order", Expected: "`order`"},
+		{Name: "MySQLSpaces", Dialect: MySQLDialect, Ident: "full `name`", Expected: "`full ``name```"},
+		{Name: "GenericLeadingDigit", Dialect: GenericDialect, Ident: "2fa", Expected: `"2fa"`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			actual := testCase.Dialect.QuoteIdentifier(testCase.Ident)
+			if actual != testCase.Expected {
+				subT.Logf("expected: %s\ngot: %s", testCase.Expected, actual)
+				subT.Fail()
+			}
+		})
+	}
+}
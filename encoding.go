@@ -0,0 +1,190 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding is a character encoding for text data.
+type Encoding uint8
+
+const (
+	// AutoEncoding detects the encoding when reading from a byte order
+	// mark, falling back to UTF-16 for text with interleaved NUL bytes
+	// and Windows-1252 for text which isn't valid UTF-8.
+	// It writes UTF-8.
+	AutoEncoding Encoding = iota
+	UTF8
+	UTF8BOM
+	UTF16LE
+	UTF16BE
+	Latin1
+	Windows1252
+)
+
+var encodingNames = []string{
+	AutoEncoding: "auto",
+	UTF8:         "utf-8",
+	UTF8BOM:      "utf-8-bom",
+	UTF16LE:      "utf-16le",
+	UTF16BE:      "utf-16be",
+	Latin1:       "latin1",
+	Windows1252:  "windows-1252",
+}
+
+var encodingAliases = map[string]Encoding{
+	"utf8":       UTF8,
+	"utf8bom":    UTF8BOM,
+	"utf-8bom":   UTF8BOM,
+	"utf16le":    UTF16LE,
+	"utf16be":    UTF16BE,
+	"iso-8859-1": Latin1,
+	"iso8859-1":  Latin1,
+	"cp1252":     Windows1252,
+}
+
+// String
+func (e Encoding) String() string {
+	if int(e) < len(encodingNames) {
+		return encodingNames[e]
+	}
+	return fmt.Sprintf("Encoding(%d)", e)
+}
+
+// ParseEncoding returns the Encoding with the given name, ignoring case.
+func ParseEncoding(name string) (Encoding, error) {
+	name = strings.ToLower(name)
+	for e, s := range encodingNames {
+		if s == name {
+			return Encoding(e), nil
+		}
+	}
+	if e, ok := encodingAliases[name]; ok {
+		return e, nil
+	}
+	return 0, fmt.Errorf("tblconv: unknown encoding: %s", name)
+}
+
+func (e Encoding) encoding() encoding.Encoding {
+	switch e {
+	case UTF8BOM:
+		return unicode.UTF8BOM
+	case UTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case UTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case Latin1:
+		return charmap.ISO8859_1
+	case Windows1252:
+		return charmap.Windows1252
+	default:
+		return unicode.UTF8
+	}
+}
+
+// detectSize is the number of bytes examined by AutoEncoding.
+const detectSize = 64 * 1024
+
+// decodeReader returns a reader which decodes r from e to UTF-8,
+// dropping any byte order mark.
+func decodeReader(r io.Reader, e Encoding) io.Reader {
+	if e == AutoEncoding {
+		br := bufio.NewReaderSize(r, detectSize)
+		b, _ := br.Peek(detectSize)
+
+		e = detectEncoding(b)
+		if e == UTF8 {
+			return br
+		}
+		r = br
+	}
+
+	if e == UTF8 {
+		// UTF8BOM decodes UTF-8 with or without a byte order mark
+		e = UTF8BOM
+	}
+	return transform.NewReader(r, e.encoding().NewDecoder())
+}
+
+// encodeWriter returns a writer which encodes UTF-8 to e before
+// writing it to w.
+func encodeWriter(w io.Writer, e Encoding) io.Writer {
+	if e == AutoEncoding || e == UTF8 {
+		return w
+	}
+	return transform.NewWriter(w, e.encoding().NewEncoder())
+}
+
+func detectEncoding(b []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return UTF8BOM
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return UTF16LE
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return UTF16BE
+	}
+
+	var even, odd int
+	for i, c := range b {
+		if c != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+	switch {
+	case odd > len(b)/4 && even < odd/8:
+		return UTF16LE
+	case even > len(b)/4 && odd < even/8:
+		return UTF16BE
+	}
+
+	// don't mistake a rune cut off by the end of b for invalid UTF-8
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				b = b[:i]
+			}
+			break
+		}
+	}
+	if !utf8.Valid(b) {
+		return Windows1252
+	}
+	return UTF8
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCSVEncoding(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    []byte
+		Encoding Encoding
+		Expected [][]string
+	}{
+		{
+			Name:     "DetectUTF8",
+			Input:    []byte("id,name\n0,zoë\n"),
+			Expected: [][]string{{"id", "name"}, {"0", "zoë"}},
+		},
+		{
+			Name:     "DetectUTF8BOM",
+			Input:    []byte("\xEF\xBB\xBFid,name\n0,zoë\n"),
+			Expected: [][]string{{"id", "name"}, {"0", "zoë"}},
+		},
+		{
+			Name:     "DetectUTF16LEBOM",
+			Input:    []byte("\xFF\xFEi\x00d\x00,\x00n\x00\n\x000\x00,\x00\xEB\x00\n\x00"),
+			Expected: [][]string{{"id", "n"}, {"0", "ë"}},
+		},
+		{
+			Name:     "DetectUTF16BEBOM",
+			Input:    []byte("\xFE\xFF\x00i\x00d\x00,\x00n\x00\n\x000\x00,\x00\xEB\x00\n"),
+			Expected: [][]string{{"id", "n"}, {"0", "ë"}},
+		},
+		{
+			Name:     "DetectUTF16LE",
+			Input:    []byte("i\x00d\x00,\x00n\x00\n\x000\x00,\x00\xEB\x00\n\x00"),
+			Expected: [][]string{{"id", "n"}, {"0", "ë"}},
+		},
+		{
+			Name:     "DetectWindows1252",
+			Input:    []byte("id,name\n0,zo\xEB \x80\n"),
+			Expected: [][]string{{"id", "name"}, {"0", "zoë €"}},
+		},
+		{
+			Name:     "Latin1",
+			Input:    []byte("id,name\n0,zo\xEB\n"),
+			Encoding: Latin1,
+			Expected: [][]string{{"id", "name"}, {"0", "zoë"}},
+		},
+		{
+			Name:     "UTF8StripsBOM",
+			Input:    []byte("\xEF\xBB\xBFid\n"),
+			Encoding: UTF8,
+			Expected: [][]string{{"id"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			r := NewCSVReader(bytes.NewReader(testCase.Input), CSVEncoding(testCase.Encoding))
+
+			var records [][]string
+			for {
+				record, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					subT.Error(err)
+					return
+				}
+				records = append(records, record)
+			}
+
+			if len(records) != len(testCase.Expected) {
+				subT.Logf("expected: %q\ngot: %q", testCase.Expected, records)
+				subT.Fail()
+				return
+			}
+			for i := range records {
+				if strings.Join(records[i], ",") != strings.Join(testCase.Expected[i], ",") {
+					subT.Logf("expected: %q\ngot: %q", testCase.Expected, records)
+					subT.Fail()
+					return
+				}
+			}
+		})
+	}
+
+	t.Run("Write", func(subT *testing.T) {
+		writeCases := []struct {
+			Encoding Encoding
+			Expected []byte
+		}{
+			{Encoding: AutoEncoding, Expected: []byte("zoë\n")},
+			{Encoding: UTF8BOM, Expected: []byte("\xEF\xBB\xBFzoë\n")},
+			{Encoding: UTF16LE, Expected: []byte("\xFF\xFEz\x00o\x00\xEB\x00\n\x00")},
+			{Encoding: UTF16BE, Expected: []byte("\xFE\xFF\x00z\x00o\x00\xEB\x00\n")},
+			{Encoding: Windows1252, Expected: []byte("zo\xEB\n")},
+		}
+
+		for _, writeCase := range writeCases {
+			var out bytes.Buffer
+			w := NewCSVWriter(&out, CSVEncoding(writeCase.Encoding))
+			w.Write([]string{"zoë"})
+
+			err := w.Flush()
+			if err != nil {
+				subT.Error(err)
+				continue
+			}
+
+			if !bytes.Equal(writeCase.Expected, out.Bytes()) {
+				subT.Logf("%s: expected: %q\ngot: %q", writeCase.Encoding, writeCase.Expected, out.Bytes())
+				subT.Fail()
+			}
+		}
+	})
+
+	t.Run("ParseEncoding", func(subT *testing.T) {
+		for _, name := range []string{"auto", "UTF-8", "utf8", "utf-8-bom", "utf-16le", "UTF-16BE", "latin1", "cp1252"} {
+			_, err := ParseEncoding(name)
+			if err != nil {
+				subT.Error(err)
+			}
+		}
+
+		_, err := ParseEncoding("ebcdic")
+		if err == nil {
+			subT.Log("expected an error for an unknown encoding")
+			subT.Fail()
+		}
+	})
+}
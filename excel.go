@@ -23,10 +23,16 @@ THE SOFTWARE.
 package tblconv
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
+	"math"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/xuri/excelize/v2"
 )
@@ -35,7 +41,25 @@ import (
 var DefaultSheetName = "Sheet1"
 
 type excelConfig struct {
-	sheet string
+	sheet         string
+	sheetIndex    int
+	sheetPattern  string
+	sheetColumn   string
+	definedName   string
+	cellRange     string
+	skipRows      int
+	stopAtEmpty   bool
+	formulas      FormulaMode
+	fillMerged    bool
+	headerRow     int
+	headerStyle   *excelize.Style
+	autoWidth     bool
+	freezeHeader  bool
+	autoFilter    bool
+	tableStyle    string
+	nullCell      string
+	columnFormats map[string]string
+	kindFormats   map[Kind]string
 }
 
 // ExcelOption
@@ -48,35 +72,512 @@ func SheetName(sheet string) ExcelOption {
 	}
 }
 
-// ExcelReader
+// SheetIndex selects the sheet to read by its position in the workbook,
+// counting from 1, instead of by name.
+func SheetIndex(index int) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.sheetIndex = index
+	}
+}
+
+// SheetPattern configures an ExcelReader to read every sheet whose name
+// matches the pattern, in the order they appear in the workbook. The
+// pattern syntax is that of path.Match, e.g. "*" matches every sheet.
+//
+// With a HeaderRow, the header of the first sheet is used as the schema
+// and the header rows of the other sheets are skipped.
+//
+func SheetPattern(pattern string) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.sheetPattern = pattern
+	}
+}
+
+// SheetColumn configures an ExcelReader to prepend a column with the given
+// name to every record, holding the name of the sheet it was read from.
+func SheetColumn(name string) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.sheetColumn = name
+	}
+}
+
+// DefinedName configures an ExcelReader to read the sheet and cell
+// range referred to by a defined name of the workbook, e.g. a name
+// defined as Sheet1!$B$4:$H$200.
+func DefinedName(name string) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.definedName = name
+	}
+}
+
+// CellRange limits an ExcelReader to the cells of a range, e.g. "B4:H200".
+// Either corner may be given as only a column or a row, e.g. "B:H" reads
+// every row of columns B through H and "4:200" every column of rows 4
+// through 200.
+func CellRange(ref string) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.cellRange = ref
+	}
+}
+
+// SkipRows configures an ExcelReader to skip the first n rows of the
+// sheet, or of the CellRange, before reading the header or any records.
+func SkipRows(n int) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.skipRows = n
+	}
+}
+
+// StopAtEmptyRow configures an ExcelReader to stop reading a sheet at the
+// first row without any values, e.g. to leave out a footer below the data.
+func StopAtEmptyRow(stop bool) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.stopAtEmpty = stop
+	}
+}
+
+// FormulaMode selects what an ExcelReader reads from formula cells.
+type FormulaMode uint8
+
+const (
+	// CachedValue reads the value computed by the application which
+	// last saved the workbook.
+	CachedValue FormulaMode = iota
+
+	// FormulaText reads the formula itself, e.g. "=SUM(A1:A3)".
+	FormulaText
+
+	// Recalculate computes the value of the formula with excelize's
+	// calculation engine. A formula it fails to compute is returned
+	// from Read as a *RecordError.
+	Recalculate
+)
+
+// Formulas sets what an ExcelReader reads from formula cells. By default,
+// it reads their cached values.
+//
+// Other modes require the sheet to be loaded into memory. Formulas are only
+// found in cells up to the last cell of a row with a value, or within the
+// width of the header or CellRange.
+//
+func Formulas(mode FormulaMode) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.formulas = mode
+	}
+}
+
+// FillMergedCells configures an ExcelReader to read the value of a merged
+// cell from every cell it covers, instead of only the first one.
+func FillMergedCells(fill bool) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.fillMerged = fill
+	}
+}
+
+// HeaderRow designates the row, counting from 1, which holds the column
+// names of the sheet. An ExcelReader exposes the header through Schema
+// and skips every row up to and including it when reading. By default,
+// no row is treated as a header.
+//
+// Rows are counted from the first row read, i.e. after the rows skipped
+// by CellRange and SkipRows.
+//
+func HeaderRow(row int) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.headerRow = row
+	}
+}
+
+// ColumnFormat sets the Excel number format, e.g. "#,##0.00", of the
+// cells an ExcelWriter writes to a column. The column is given by name,
+// as in the schema given to WriteSchema, or by position counting from 1.
+func ColumnFormat(column, format string) ExcelOption {
+	return func(cfg *excelConfig) {
+		if cfg.columnFormats == nil {
+			cfg.columnFormats = make(map[string]string)
+		}
+		cfg.columnFormats[column] = format
+	}
+}
+
+// KindFormat sets the Excel number format of the cells an ExcelWriter
+// writes for values of Kind k, unless the column has its own ColumnFormat.
+// By default, integers are formatted as "0", dates as "yyyy-mm-dd" and
+// timestamps as "yyyy-mm-dd hh:mm:ss".
+func KindFormat(k Kind, format string) ExcelOption {
+	return func(cfg *excelConfig) {
+		if cfg.kindFormats == nil {
+			cfg.kindFormats = make(map[Kind]string)
+		}
+		cfg.kindFormats[k] = format
+	}
+}
+
+// HeaderStyle sets the style of the first row an ExcelWriter writes,
+// which is normally the header written by WriteSchema, e.g.
+//
+//	HeaderStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+//
+func HeaderStyle(style *excelize.Style) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.headerStyle = style
+	}
+}
+
+// AutoWidth configures an ExcelWriter to size its columns to fit their
+// contents. Widths are based on the first rows written, which are held
+// in memory until the widths are set.
+func AutoWidth(auto bool) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.autoWidth = auto
+	}
+}
+
+// FreezeHeader configures an ExcelWriter to freeze the first row,
+// so the header stays visible when scrolling.
+func FreezeHeader(freeze bool) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.freezeHeader = freeze
+	}
+}
+
+// AutoFilter configures an ExcelWriter to add an autofilter over
+// the rows written, using the first row as the header.
+func AutoFilter(filter bool) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.autoFilter = filter
+	}
+}
+
+// Table configures an ExcelWriter to format the rows written as an Excel
+// table with the given style, e.g. "TableStyleMedium2", using the first row
+// as the header. Header names must be unique. A table has its own filter,
+// so AutoFilter is ignored.
+func Table(style string) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.tableStyle = style
+	}
+}
+
+// NullCell sets the text of the cells an ExcelWriter writes for NULL
+// values, e.g. "NULL". By default, NULL values are left as empty cells.
+func NullCell(text string) ExcelOption {
+	return func(cfg *excelConfig) {
+		cfg.nullCell = text
+	}
+}
+
+// ExcelReader reads the rows of a sheet one at a time, so only the
+// current row is held in memory rather than the whole sheet.
 type ExcelReader struct {
+	sheetReader
+}
+
+// Abort closes the workbook, removing any temporary files.
+func (r *ExcelReader) Abort() error {
+	return r.sheetReader.Abort()
+}
+
+// excelBook is a workbook read by an ExcelReader.
+type excelBook struct {
 	cfg  excelConfig
-	open func() (*excelize.File, error)
+	file *excelize.File
+}
+
+func (b *excelBook) sheetNames() []string {
+	return b.file.GetSheetList()
+}
 
-	idx  int
-	rows [][]string
+func (b *excelBook) definedRange(name string) (string, string, error) {
+	return definedRange(b.file, name)
 }
 
-// Read
-func (r *ExcelReader) Read() ([]string, error) {
-	if r.rows == nil {
-		f, err := r.open()
+func (b *excelBook) rows(sheet string) (sheetRows, error) {
+	rows, err := b.file.Rows(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	sr := &excelRows{book: b, sheet: sheet, rows: rows}
+	if b.cfg.fillMerged {
+		err = sr.loadMerges()
 		if err != nil {
+			rows.Close()
 			return nil, err
 		}
+	}
+	return sr, nil
+}
+
+func (b *excelBook) close() error {
+	return b.file.Close()
+}
+
+// excelRows streams the rows of a sheet with excelize.
+type excelRows struct {
+	book   *excelBook
+	sheet  string
+	rows   *excelize.Rows
+	num    int
+	merges []mergedCell
+}
+
+func (r *excelRows) next() (int, []string, bool, error) {
+	if !r.rows.Next() {
+		return 0, nil, false, nil
+	}
+	r.num += 1
 
-		r.rows, err = f.GetRows(r.cfg.sheet)
+	row, err := r.rows.Columns()
+	if err != nil {
+		return 0, nil, false, err
+	}
+	return r.num, row, true, nil
+}
+
+// fill reads the formula cells of a row according to the FormulaMode
+// and fills in the cells covered by merged cells.
+func (r *excelRows) fill(num int, row []string, width int) ([]string, error) {
+	if r.book.cfg.formulas != CachedValue {
+		var err error
+		row, err = r.evalFormulas(num, row, width)
 		if err != nil {
 			return nil, err
 		}
 	}
+	for _, m := range r.merges {
+		row = m.fill(num, row)
+	}
+	return row, nil
+}
+
+// evalFormulas replaces the cached values of the formula cells
+// of a row according to the FormulaMode.
+func (r *excelRows) evalFormulas(num int, row []string, width int) ([]string, error) {
+	if len(row) > width {
+		width = len(row)
+	}
+
+	for col := 1; col <= width; col++ {
+		cached := ""
+		if col <= len(row) {
+			cached = row[col-1]
+		}
+
+		val, err := r.formulaValue(getCellId(num, col), cached)
+		if err != nil {
+			return nil, &RecordError{Record: row, Err: err}
+		}
+		if val == cached {
+			continue
+		}
+
+		for len(row) < col {
+			row = append(row, "")
+		}
+		row[col-1] = val
+	}
+	return row, nil
+}
+
+// formulaValue returns the value of a cell according to the FormulaMode.
+// cached is returned if the cell has no formula.
+func (r *excelRows) formulaValue(cell, cached string) (string, error) {
+	if r.book.cfg.formulas == CachedValue {
+		return cached, nil
+	}
+
+	formula, err := r.book.file.GetCellFormula(r.sheet, cell)
+	if err != nil || formula == "" {
+		return cached, err
+	}
+
+	if r.book.cfg.formulas == FormulaText {
+		return "=" + formula, nil
+	}
+
+	val, err := r.book.file.CalcCellValue(r.sheet, cell)
+	if err != nil {
+		return "", fmt.Errorf("tblconv: %s!%s: %w", r.sheet, cell, err)
+	}
+	return val, nil
+}
+
+func (r *excelRows) close() error {
+	return r.rows.Close()
+}
+
+type mergedCell struct {
+	cellRange
+	value string
+}
+
+// fill sets the cells of row covered by m, if it is the given row number.
+func (m mergedCell) fill(rowNum int, row []string) []string {
+	if rowNum < m.top || rowNum > m.bottom {
+		return row
+	}
+
+	for len(row) < m.right {
+		row = append(row, "")
+	}
+	for col := m.left; col <= m.right; col++ {
+		row[col-1] = m.value
+	}
+	return row
+}
+
+func (r *excelRows) loadMerges() error {
+	merges, err := r.book.file.GetMergeCells(r.sheet)
+	if err != nil {
+		return err
+	}
+
+	r.merges = make([]mergedCell, 0, len(merges))
+	for _, m := range merges {
+		rng, err := parseCellRange(m.GetStartAxis() + ":" + m.GetEndAxis())
+		if err != nil {
+			return err
+		}
+
+		value, err := r.formulaValue(m.GetStartAxis(), m.GetCellValue())
+		if err != nil {
+			return err
+		}
+		r.merges = append(r.merges, mergedCell{cellRange: rng, value: value})
+	}
+	return nil
+}
+
+func isEmptyRow(row []string) bool {
+	for _, field := range row {
+		if field != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// cellRange is a range of cells, counting from 1. A zero bound
+// is unbounded.
+type cellRange struct {
+	left, top, right, bottom int
+}
+
+func parseCellRange(ref string) (cellRange, error) {
+	var rng cellRange
+	if ref == "" {
+		return rng, nil
+	}
+
+	from, to, ok := strings.Cut(strings.ReplaceAll(ref, "$", ""), ":")
+	if !ok {
+		to = from
+	}
+
+	var err error
+	rng.left, rng.top, err = parseCellRef(from)
+	if err != nil {
+		return rng, fmt.Errorf("tblconv: invalid cell range: %s: %w", ref, err)
+	}
+	rng.right, rng.bottom, err = parseCellRef(to)
+	if err != nil {
+		return rng, fmt.Errorf("tblconv: invalid cell range: %s: %w", ref, err)
+	}
+
+	if (rng.right > 0 && rng.right < rng.left) || (rng.bottom > 0 && rng.bottom < rng.top) {
+		return rng, fmt.Errorf("tblconv: invalid cell range: %s", ref)
+	}
+	return rng, nil
+}
+
+// parseCellRef parses a cell reference, e.g. "B4", or only its
+// column or row, e.g. "B" or "4".
+func parseCellRef(ref string) (col, row int, err error) {
+	i := strings.IndexFunc(ref, func(c rune) bool { return c >= '0' && c <= '9' })
+	switch {
+	case i < 0:
+		col, err = excelize.ColumnNameToNumber(ref)
+	case i == 0:
+		row, err = strconv.Atoi(ref)
+	default:
+		col, row, err = excelize.CellNameToCoordinates(ref)
+	}
+	return
+}
+
+// columns returns the cells of row within the range.
+func (rng cellRange) columns(row []string) []string {
+	left := 1
+	if rng.left > 1 {
+		left = rng.left
+		if len(row) < left {
+			return []string{}
+		}
+		row = row[left-1:]
+	}
+	if rng.right > 0 {
+		if width := rng.right - left + 1; len(row) > width {
+			row = row[:width]
+		}
+	}
+	return row
+}
+
+// definedRange returns the sheet and cell range referred to
+// by a defined name.
+func definedRange(f *excelize.File, name string) (string, string, error) {
+	for _, dn := range f.GetDefinedName() {
+		if dn.Name != name {
+			continue
+		}
+
+		i := strings.LastIndex(dn.RefersTo, "!")
+		if i < 0 {
+			return "", "", fmt.Errorf("tblconv: defined name %s does not refer to a range: %s", name, dn.RefersTo)
+		}
+
+		sheet := strings.TrimPrefix(dn.RefersTo[:i], "=")
+		if strings.HasPrefix(sheet, "'") && strings.HasSuffix(sheet, "'") {
+			sheet = strings.ReplaceAll(sheet[1:len(sheet)-1], "''", "'")
+		}
+		return sheet, dn.RefersTo[i+1:], nil
+	}
+	return "", "", fmt.Errorf("tblconv: unknown defined name: %s", name)
+}
 
-	if r.idx == len(r.rows) {
-		return nil, io.EOF
+// sheetsOf returns the names of the sheets to read out of the
+// sheets of a workbook. Without a sheet name, the first sheet is read.
+func (cfg excelConfig) sheetsOf(list []string) ([]string, error) {
+	switch {
+	case cfg.sheetPattern != "":
+		var sheets []string
+		for _, sheet := range list {
+			ok, err := path.Match(cfg.sheetPattern, sheet)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				sheets = append(sheets, sheet)
+			}
+		}
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("tblconv: no sheet matches: %s", cfg.sheetPattern)
+		}
+		return sheets, nil
+	case cfg.sheetIndex > 0:
+		if cfg.sheetIndex > len(list) {
+			return nil, fmt.Errorf("tblconv: no sheet at index %d", cfg.sheetIndex)
+		}
+		return []string{list[cfg.sheetIndex-1]}, nil
+	case cfg.sheet == "":
+		if len(list) == 0 {
+			return nil, fmt.Errorf("tblconv: workbook has no sheets")
+		}
+		return list[:1], nil
+	default:
+		return []string{cfg.sheet}, nil
 	}
-	idx := r.idx
-	r.idx += 1
-	return r.rows[idx], nil
 }
 
 // NewExcelReader
@@ -90,32 +591,316 @@ func NewExcelReader(r io.Reader, opts ...ExcelOption) *ExcelReader {
 	}
 
 	return &ExcelReader{
-		cfg:  cfg,
-		open: func() (*excelize.File, error) { return excelize.OpenReader(r) },
+		sheetReader: sheetReader{
+			cfg: cfg,
+			open: func() (spreadsheet, error) {
+				f, err := excelize.OpenReader(r)
+				if err != nil {
+					return nil, err
+				}
+				return &excelBook{cfg: cfg, file: f}, nil
+			},
+		},
 	}
 }
 
-// ExcelWriter
+// ExcelWriter writes records to a sheet as they arrive, using
+// excelize's StreamWriter, so they are not kept in memory
+// until Flush.
+//
+// Typed values are written as cells of their native type, formatted
+// according to ColumnFormat and KindFormat. Records written as strings
+// are converted to the kinds of the schema given to WriteSchema, if any.
+//
 type ExcelWriter struct {
 	flushOnce sync.Once
 
-	cfg   excelConfig
-	out   io.Writer
-	excel *excelize.File
+	cfg    excelConfig
+	out    io.Writer
+	excel  *excelize.File
+	stream *excelize.StreamWriter
 
-	idx int
+	idx     int
+	width   int
+	pending [][]interface{}
+	columns []Column
+	formats []string
+	styles  map[string]int
 }
 
 // Write
 func (w *ExcelWriter) Write(record []string) error {
+	if w.columns == nil {
+		row := make([]interface{}, len(record))
+		for i, val := range record {
+			row[i] = val
+		}
+		return w.writeRow(row)
+	}
+
+	vals := make([]Value, len(record))
+	for i, field := range record {
+		vals[i] = StringValue(field)
+		if i < len(w.columns) && w.columns[i].Kind != StringKind && field != "" {
+			if val, err := ParseValue(field, w.columns[i].Kind); err == nil {
+				vals[i] = val
+			}
+		}
+	}
+	return w.WriteTyped(vals)
+}
+
+// WriteSchema writes the column names as a header row.
+func (w *ExcelWriter) WriteSchema(columns []Column) error {
+	err := w.Write(columnNames(columns))
+	if err != nil {
+		return err
+	}
+
+	for _, col := range columns {
+		if col.Kind != StringKind {
+			w.columns = columns
+			break
+		}
+	}
+	return w.bindFormats(columns)
+}
+
+// WriteTyped writes each value using its native type, e.g. numbers are
+// written as numeric cells instead of text. Binary values are written
+// as hex. NULL values are left as empty cells, unless configured with
+// NullCell.
+func (w *ExcelWriter) WriteTyped(record []Value) error {
+	if w.formats == nil {
+		err := w.bindFormats(nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	row := make([]interface{}, len(record))
 	for i, val := range record {
-		cellId := getCellId(w.idx+1, i+1)
-		w.excel.SetCellStr(w.cfg.sheet, cellId, val)
+		if val.Null {
+			if w.cfg.nullCell != "" {
+				row[i] = w.cfg.nullCell
+			}
+			continue
+		}
+
+		format := w.cfg.kindFormats[val.Kind]
+		if i < len(w.formats) && w.formats[i] != "" {
+			format = w.formats[i]
+		}
+		if format == "" {
+			row[i] = excelValue(val)
+			continue
+		}
+
+		style, err := w.style(format)
+		if err != nil {
+			return err
+		}
+		row[i] = excelize.Cell{StyleID: style, Value: excelValue(val)}
+	}
+	return w.writeRow(row)
+}
+
+// bindFormats resolves the columns given to ColumnFormat. Without
+// a schema, only positions can be resolved.
+func (w *ExcelWriter) bindFormats(columns []Column) error {
+	w.formats = []string{}
+	for column, format := range w.cfg.columnFormats {
+		i, err := columnIndex(columns, column)
+		if err != nil {
+			pos, perr := strconv.Atoi(column)
+			if columns != nil || perr != nil || pos < 1 {
+				return err
+			}
+			i = pos - 1
+		}
+
+		for len(w.formats) <= i {
+			w.formats = append(w.formats, "")
+		}
+		w.formats[i] = format
+	}
+	return nil
+}
+
+func (w *ExcelWriter) style(format string) (int, error) {
+	if style, ok := w.styles[format]; ok {
+		return style, nil
+	}
+
+	style, err := w.excel.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		return 0, err
+	}
+	w.styles[format] = style
+	return style, nil
+}
+
+// autoWidthRows is the number of rows AutoWidth sizes columns by.
+const autoWidthRows = 100
+
+func (w *ExcelWriter) writeRow(row []interface{}) error {
+	if w.stream == nil {
+		if w.cfg.autoWidth && len(w.pending) < autoWidthRows {
+			w.pending = append(w.pending, row)
+			return nil
+		}
+
+		err := w.startStream()
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.idx == 0 && w.cfg.headerStyle != nil {
+		style, err := w.excel.NewStyle(w.cfg.headerStyle)
+		if err != nil {
+			return err
+		}
+
+		header := make([]interface{}, len(row))
+		for i, val := range row {
+			header[i] = excelize.Cell{StyleID: style, Value: val}
+		}
+		row = header
+	}
+
+	err := w.stream.SetRow(getCellId(w.idx+1, 1), row)
+	if err != nil {
+		return err
 	}
 	w.idx += 1
+	if len(row) > w.width {
+		w.width = len(row)
+	}
+	return nil
+}
+
+// startStream starts streaming the sheet, after applying the settings
+// which must precede its rows, and writes any pending rows.
+func (w *ExcelWriter) startStream() error {
+	if w.cfg.freezeHeader {
+		err := w.excel.SetPanes(w.cfg.sheet, `{"freeze":true,"split":false,"x_split":0,"y_split":1,"top_left_cell":"A2","active_pane":"bottomLeft"}`)
+		if err != nil {
+			return err
+		}
+	}
+
+	stream, err := w.excel.NewStreamWriter(w.cfg.sheet)
+	if err != nil {
+		return err
+	}
+	w.stream = stream
+
+	if w.cfg.autoWidth {
+		for i, width := range columnWidths(w.pending) {
+			err = stream.SetColWidth(i+1, i+1, width)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	pending := w.pending
+	w.pending = nil
+	for _, row := range pending {
+		err = w.writeRow(row)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// finishStream adds the table or autofilter over the rows written
+// and flushes the sheet.
+func (w *ExcelWriter) finishStream() error {
+	if w.stream == nil {
+		if len(w.pending) == 0 {
+			return nil
+		}
+
+		err := w.startStream()
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.idx > 1 && w.width > 0 {
+		last := getCellId(w.idx, w.width)
+
+		var err error
+		switch {
+		case w.cfg.tableStyle != "":
+			err = w.stream.AddTable("A1", last, fmt.Sprintf(`{"table_style":%q,"show_row_stripes":true}`, w.cfg.tableStyle))
+		case w.cfg.autoFilter:
+			err = w.excel.AutoFilter(w.cfg.sheet, "A1", last, "")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.stream.Flush()
+}
+
+// columnWidths returns the width of each column needed to fit its
+// widest cell, in characters.
+func columnWidths(rows [][]interface{}) []float64 {
+	var widths []float64
+	for _, row := range rows {
+		for i, val := range row {
+			for len(widths) <= i {
+				widths = append(widths, 8)
+			}
+			if width := float64(cellWidth(val) + 2); width > widths[i] {
+				widths[i] = math.Min(width, 255)
+			}
+		}
+	}
+	return widths
+}
+
+func cellWidth(val interface{}) int {
+	switch x := val.(type) {
+	case nil:
+		return 0
+	case excelize.Cell:
+		return cellWidth(x.Value)
+	case string:
+		return utf8.RuneCountInString(x)
+	case time.Time:
+		return len("2006-01-02 15:04:05")
+	case float64:
+		return len(formatFloat(x))
+	default:
+		return len(fmt.Sprint(x))
+	}
+}
+
+func excelValue(val Value) interface{} {
+	switch val.Kind {
+	case DecimalKind:
+		f, err := strconv.ParseFloat(val.String(), 64)
+		if err != nil {
+			return val.String()
+		}
+		return f
+	case BytesKind:
+		// raw bytes may hold characters which are invalid in the XML
+		// of a sheet, so they're written as hex like a SQLReader would
+		if b, ok := val.V.([]byte); ok {
+			return hex.EncodeToString(b)
+		}
+		return val.String()
+	default:
+		return val.V
+	}
+}
+
 func getCellId(row, col int) string {
 	colName := ""
 	for col > 0 {
@@ -129,20 +914,36 @@ func getCellId(row, col int) string {
 // Flush
 func (w *ExcelWriter) Flush() (err error) {
 	w.flushOnce.Do(func() {
+		if w.out != nil {
+			defer w.excel.Close()
+		}
+
+		err = w.finishStream()
+		if err != nil {
+			return
+		}
+
+		// the workbook is written by its Workbook
+		if w.out == nil {
+			return
+		}
 		_, err = w.excel.WriteTo(w.out)
 	})
 	return
 }
 
-// NewExcelWriter
-func NewExcelWriter(w io.Writer, opts ...ExcelOption) *ExcelWriter {
-	cfg := excelConfig{
-		sheet: "Sheet1",
+// Abort discards the records written so far, removing any
+// temporary files.
+func (w *ExcelWriter) Abort() error {
+	if w.out == nil {
+		return nil
 	}
+	return w.excel.Close()
+}
 
-	for _, opt := range opts {
-		opt(&cfg)
-	}
+// NewExcelWriter
+func NewExcelWriter(w io.Writer, opts ...ExcelOption) *ExcelWriter {
+	cfg := newExcelWriterConfig(opts)
 
 	f := excelize.NewFile()
 
@@ -156,13 +957,31 @@ func NewExcelWriter(w io.Writer, opts ...ExcelOption) *ExcelWriter {
 	}
 
 	if !hasSheet {
-		idx := f.NewSheet(cfg.sheet)
-		f.SetActiveSheet(idx)
+		f.NewSheet(cfg.sheet)
+		f.DeleteSheet("Sheet1")
+		f.SetActiveSheet(f.GetSheetIndex(cfg.sheet))
 	}
 
 	return &ExcelWriter{
-		cfg:   cfg,
-		out:   w,
-		excel: excelize.NewFile(),
+		cfg:    cfg,
+		out:    w,
+		excel:  f,
+		styles: make(map[string]int),
+	}
+}
+
+func newExcelWriterConfig(opts []ExcelOption) excelConfig {
+	cfg := excelConfig{
+		sheet: "Sheet1",
+		kindFormats: map[Kind]string{
+			IntKind:  "0",
+			DateKind: "yyyy-mm-dd",
+			TimeKind: "yyyy-mm-dd hh:mm:ss",
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
 	}
+	return cfg
 }
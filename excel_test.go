@@ -23,7 +23,17 @@ THE SOFTWARE.
 package tblconv
 
 import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
 )
 
 func TestGetCellId(t *testing.T) {
@@ -70,3 +80,682 @@ func TestGetCellId(t *testing.T) {
 		})
 	}
 }
+
+func TestExcelReader(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"id", "name"})
+	f.SetSheetRow("Sheet1", "A2", &[]interface{}{0, "tony"})
+	f.SetSheetRow("Sheet1", "A4", &[]interface{}{1, "peter"})
+	f.SetRowHeight("Sheet1", 6, 30)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	testCases := []struct {
+		Name     string
+		Opts     []ExcelOption
+		Expected [][]string
+	}{
+		{
+			Name:     "AllRows",
+			Expected: [][]string{{"id", "name"}, {"0", "tony"}, {}, {"1", "peter"}},
+		},
+		{
+			Name:     "HeaderRow",
+			Opts:     []ExcelOption{HeaderRow(1)},
+			Expected: [][]string{{"0", "tony"}, {}, {"1", "peter"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			r := NewExcelReader(bytes.NewReader(buf.Bytes()), testCase.Opts...)
+
+			var records [][]string
+			for {
+				record, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					subT.Error(err)
+					return
+				}
+				records = append(records, record)
+			}
+
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(records) {
+				subT.Logf("expected: %q\ngot: %q", testCase.Expected, records)
+				subT.Fail()
+				return
+			}
+		})
+	}
+}
+
+func TestExcelWriter(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Sheet    string
+		Reader   func() Reader
+		Expected [][]string
+	}{
+		{
+			Name:  "Strings",
+			Sheet: "Sheet1",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"id", "name"}, []string{"0", "tony"})
+			},
+			Expected: [][]string{{"id", "name"}, {"0", "tony"}},
+		},
+		{
+			Name:  "SheetName",
+			Sheet: "users",
+			Reader: func() Reader {
+				return NewInferReader(NewRecordsReader([]string{"id", "name"}, []string{"0", "tony"}, []string{"1", ""}))
+			},
+			Expected: [][]string{{"id", "name"}, {"0", "tony"}, {"1"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			var buf bytes.Buffer
+			err := Copy(NewExcelWriter(&buf, SheetName(testCase.Sheet)), testCase.Reader())
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			f, err := excelize.OpenReader(&buf)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+			if sheets := f.GetSheetList(); len(sheets) != 1 || sheets[0] != testCase.Sheet {
+				subT.Logf("expected only sheet %s\ngot: %v", testCase.Sheet, sheets)
+				subT.Fail()
+				return
+			}
+
+			rows, err := f.GetRows(testCase.Sheet)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+			if fmt.Sprint(testCase.Expected) != fmt.Sprint(rows) {
+				subT.Logf("expected: %q\ngot: %q", testCase.Expected, rows)
+				subT.Fail()
+				return
+			}
+		})
+	}
+}
+
+func TestExcelWriterFormats(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Reader   func() Reader
+		Opts     []ExcelOption
+		Expected map[string]string
+		Formats  map[string]string
+	}{
+		{
+			Name: "TypedValues",
+			Reader: func() Reader {
+				return NewInferReader(NewRecordsReader(
+					[]string{"id", "price", "active", "born"},
+					[]string{"7", "12.5", "true", "1970-05-29"},
+				))
+			},
+			Opts: []ExcelOption{ColumnFormat("price", "0.00")},
+			Expected: map[string]string{
+				"A2": "7",
+				"B2": "12.5",
+				"C2": "TRUE",
+				"D2": "1970-05-29",
+			},
+			Formats: map[string]string{
+				"A2": "0",
+				"B2": "0.00",
+				"D2": "yyyy-mm-dd",
+			},
+		},
+		{
+			Name: "StringsWithTypedSchema",
+			Reader: func() Reader {
+				return NewTransformReader(NewInferReader(NewRecordsReader(
+					[]string{"id", "born"},
+					[]string{"7", "1970-05-29"},
+				)))
+			},
+			Opts: []ExcelOption{KindFormat(DateKind, "dd/mm/yyyy")},
+			Expected: map[string]string{
+				"A1": "id",
+				"A2": "7",
+				"B2": "29/05/1970",
+			},
+			Formats: map[string]string{
+				"A1": "",
+				"A2": "0",
+			},
+		},
+		{
+			Name: "NullCell",
+			Reader: func() Reader {
+				return NewInferReader(NewRecordsReader(
+					[]string{"id", "born"},
+					[]string{"7", ""},
+				))
+			},
+			Opts: []ExcelOption{NullCell("NULL")},
+			Expected: map[string]string{
+				"A2": "7",
+				"B2": "NULL",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			var buf bytes.Buffer
+			err := Copy(NewExcelWriter(&buf, testCase.Opts...), testCase.Reader())
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			f, err := excelize.OpenReader(&buf)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			for cell, expected := range testCase.Expected {
+				actual, err := f.GetCellValue("Sheet1", cell)
+				if err != nil {
+					subT.Error(err)
+					return
+				}
+				if expected != actual {
+					subT.Logf("%s: expected: %q\ngot: %q", cell, expected, actual)
+					subT.Fail()
+				}
+			}
+
+			for cell, expected := range testCase.Formats {
+				style, err := f.GetCellStyle("Sheet1", cell)
+				if err != nil {
+					subT.Error(err)
+					return
+				}
+
+				actual := ""
+				if style > 0 {
+					id := f.Styles.CellXfs.Xf[style].NumFmtID
+					for _, numFmt := range f.Styles.NumFmts.NumFmt {
+						if numFmt.NumFmtID == *id {
+							actual = numFmt.FormatCode
+						}
+					}
+				}
+				if expected != actual {
+					subT.Logf("%s: expected format: %q\ngot: %q", cell, expected, actual)
+					subT.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestExcelReaderRange(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", "Quarterly Report")
+	f.SetSheetRow("Sheet1", "B4", &[]interface{}{"id", "name", "amount"})
+	f.SetSheetRow("Sheet1", "A5", &[]interface{}{"x", 0, "tony", 10})
+	f.SetSheetRow("Sheet1", "B6", &[]interface{}{1, "peter", 20})
+	f.SetSheetRow("Sheet1", "B7", &[]interface{}{2, "natasha", 30, "y"})
+	f.SetSheetRow("Sheet1", "B9", &[]interface{}{"total", "", 60})
+	f.SetDefinedName(&excelize.DefinedName{Name: "data", RefersTo: "Sheet1!$B$4:$D$7"})
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	data := [][]string{{"id", "name", "amount"}, {"0", "tony", "10"}, {"1", "peter", "20"}, {"2", "natasha", "30"}}
+
+	testCases := []struct {
+		Name     string
+		Opts     []ExcelOption
+		Expected [][]string
+	}{
+		{
+			Name:     "CellRange",
+			Opts:     []ExcelOption{CellRange("B4:D7"), HeaderRow(1)},
+			Expected: data,
+		},
+		{
+			Name:     "SkipRowsAndStopAtEmptyRow",
+			Opts:     []ExcelOption{CellRange("B:D"), SkipRows(3), HeaderRow(1), StopAtEmptyRow(true)},
+			Expected: data,
+		},
+		{
+			Name:     "SkipRows",
+			Opts:     []ExcelOption{CellRange("B:D"), SkipRows(3), HeaderRow(1)},
+			Expected: append(data, []string{}, []string{"total", "", "60"}),
+		},
+		{
+			Name:     "DefinedName",
+			Opts:     []ExcelOption{DefinedName("data"), HeaderRow(1)},
+			Expected: data,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			var out bytes.Buffer
+			err := Copy(NewCSVWriter(&out), NewExcelReader(bytes.NewReader(buf.Bytes()), testCase.Opts...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			var expected bytes.Buffer
+			ew := NewCSVWriter(&expected)
+			for _, record := range testCase.Expected {
+				ew.Write(record)
+			}
+			ew.Flush()
+
+			if expected.String() != out.String() {
+				subT.Logf("expected: %q\ngot: %q", expected.String(), out.String())
+				subT.Fail()
+				return
+			}
+		})
+	}
+
+	t.Run("InvalidRange", func(subT *testing.T) {
+		for _, ref := range []string{"D4:B7", "B4:B1", "4B"} {
+			_, err := NewExcelReader(bytes.NewReader(buf.Bytes()), CellRange(ref)).Read()
+			if err == nil {
+				subT.Logf("expected an error for range: %s", ref)
+				subT.Fail()
+			}
+		}
+	})
+}
+
+func TestExcelReaderFormulas(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", "budget")
+	f.MergeCell("Sheet1", "A1", "C1")
+	f.SetSheetRow("Sheet1", "A2", &[]interface{}{"q1", "q2", "total"})
+	f.SetSheetRow("Sheet1", "A3", &[]interface{}{10, 20})
+	f.SetCellFormula("Sheet1", "C3", "SUM(A3:B3)")
+	f.SetCellValue("Sheet1", "A4", "n/a")
+	f.MergeCell("Sheet1", "A4", "A5")
+	f.SetCellValue("Sheet1", "B5", 1)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	testCases := []struct {
+		Name     string
+		Opts     []ExcelOption
+		Expected [][]string
+	}{
+		{
+			Name:     "CachedValue",
+			Opts:     []ExcelOption{HeaderRow(2)},
+			Expected: [][]string{{"q1", "q2", "total"}, {"10", "20", ""}, {"n/a"}, {"", "1"}},
+		},
+		{
+			Name:     "FormulaText",
+			Opts:     []ExcelOption{HeaderRow(2), Formulas(FormulaText)},
+			Expected: [][]string{{"q1", "q2", "total"}, {"10", "20", "=SUM(A3:B3)"}, {"n/a"}, {"", "1"}},
+		},
+		{
+			Name:     "Recalculate",
+			Opts:     []ExcelOption{HeaderRow(2), Formulas(Recalculate)},
+			Expected: [][]string{{"q1", "q2", "total"}, {"10", "20", "30"}, {"n/a"}, {"", "1"}},
+		},
+		{
+			Name:     "FillMergedCells",
+			Opts:     []ExcelOption{FillMergedCells(true)},
+			Expected: [][]string{{"budget", "budget", "budget"}, {"q1", "q2", "total"}, {"10", "20", ""}, {"n/a"}, {"n/a", "1"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			var out bytes.Buffer
+			err := Copy(NewCSVWriter(&out), NewExcelReader(bytes.NewReader(buf.Bytes()), testCase.Opts...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			var expected bytes.Buffer
+			ew := NewCSVWriter(&expected)
+			for _, record := range testCase.Expected {
+				ew.Write(record)
+			}
+			ew.Flush()
+
+			if expected.String() != out.String() {
+				subT.Logf("expected: %q\ngot: %q", expected.String(), out.String())
+				subT.Fail()
+				return
+			}
+		})
+	}
+}
+
+func TestExcelReaderSheets(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetSheetName("Sheet1", "2022-01")
+	f.NewSheet("2022-02")
+	f.NewSheet("summary")
+	f.SetSheetRow("2022-01", "A1", &[]interface{}{"id", "amount"})
+	f.SetSheetRow("2022-01", "A2", &[]interface{}{0, 10})
+	f.SetSheetRow("2022-02", "A1", &[]interface{}{"id", "amount"})
+	f.SetSheetRow("2022-02", "A2", &[]interface{}{1, 20})
+	f.SetSheetRow("summary", "A1", &[]interface{}{"total", 30})
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	testCases := []struct {
+		Name     string
+		Opts     []ExcelOption
+		Expected [][]string
+	}{
+		{
+			Name:     "SheetIndex",
+			Opts:     []ExcelOption{SheetIndex(3)},
+			Expected: [][]string{{"total", "30"}},
+		},
+		{
+			Name:     "AllSheets",
+			Opts:     []ExcelOption{SheetPattern("*")},
+			Expected: [][]string{{"id", "amount"}, {"0", "10"}, {"id", "amount"}, {"1", "20"}, {"total", "30"}},
+		},
+		{
+			Name:     "PatternWithHeaderAndSheetColumn",
+			Opts:     []ExcelOption{SheetPattern("2022-*"), HeaderRow(1), SheetColumn("month")},
+			Expected: [][]string{{"month", "id", "amount"}, {"2022-01", "0", "10"}, {"2022-02", "1", "20"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			var out bytes.Buffer
+			err := Copy(NewCSVWriter(&out), NewExcelReader(bytes.NewReader(buf.Bytes()), testCase.Opts...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			var expected bytes.Buffer
+			ew := NewCSVWriter(&expected)
+			for _, record := range testCase.Expected {
+				ew.Write(record)
+			}
+			ew.Flush()
+
+			if expected.String() != out.String() {
+				subT.Logf("expected: %q\ngot: %q", expected.String(), out.String())
+				subT.Fail()
+				return
+			}
+		})
+	}
+
+	t.Run("NoMatch", func(subT *testing.T) {
+		_, err := NewExcelReader(bytes.NewReader(buf.Bytes()), SheetPattern("2023-*")).Read()
+		if err == nil {
+			subT.Log("expected an error when no sheet matches")
+			subT.Fail()
+		}
+	})
+}
+
+func TestExcelWriterStyles(t *testing.T) {
+	records := [][]string{{"id", "name"}, {"0", "tony stark"}, {"1", "peter"}}
+
+	testCases := []struct {
+		Name     string
+		Opts     []ExcelOption
+		Contains map[string]string
+		Missing  map[string]string
+	}{
+		{
+			Name: "Plain",
+			Missing: map[string]string{
+				"xl/worksheets/sheet1.xml": "<pane",
+				"xl/workbook.xml":          "_FilterDatabase",
+			},
+		},
+		{
+			Name: "FreezeAndAutoFilter",
+			Opts: []ExcelOption{FreezeHeader(true), AutoFilter(true)},
+			Contains: map[string]string{
+				"xl/worksheets/sheet1.xml": `state="frozen" topLeftCell="A2"`,
+				"xl/workbook.xml":          "_FilterDatabase",
+			},
+		},
+		{
+			Name: "Table",
+			Opts: []ExcelOption{Table("TableStyleMedium2"), AutoFilter(true)},
+			Contains: map[string]string{
+				"xl/tables/table1.xml": `ref="A1:B3"`,
+			},
+			Missing: map[string]string{
+				"xl/workbook.xml": "_FilterDatabase",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			var buf bytes.Buffer
+			err := Copy(NewExcelWriter(&buf, testCase.Opts...), NewRecordsReader(records...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			files := unzip(subT, buf.Bytes())
+			for name, s := range testCase.Contains {
+				if !strings.Contains(files[name], s) {
+					subT.Logf("expected %s to contain: %s\ngot: %s", name, s, files[name])
+					subT.Fail()
+				}
+			}
+			for name, s := range testCase.Missing {
+				if strings.Contains(files[name], s) {
+					subT.Logf("expected %s not to contain: %s\ngot: %s", name, s, files[name])
+					subT.Fail()
+				}
+			}
+		})
+	}
+
+	t.Run("HeaderStyleAndAutoWidth", func(subT *testing.T) {
+		var buf bytes.Buffer
+		w := NewExcelWriter(&buf, HeaderStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}), AutoWidth(true))
+		err := Copy(w, NewRecordsReader(records...))
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		f, err := excelize.OpenReader(&buf)
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		for cell, styled := range map[string]bool{"A1": true, "B1": true, "A2": false} {
+			style, err := f.GetCellStyle("Sheet1", cell)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+			if styled != (style != 0) {
+				subT.Logf("%s: expected styled: %v\ngot style: %d", cell, styled, style)
+				subT.Fail()
+			}
+		}
+
+		for col, expected := range map[string]float64{"A": 8, "B": 12} {
+			width, err := f.GetColWidth("Sheet1", col)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+			if expected != width {
+				subT.Logf("%s: expected width: %v\ngot: %v", col, expected, width)
+				subT.Fail()
+			}
+		}
+	})
+}
+
+func unzip(t *testing.T, b []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string, len(zr.File))
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[zf.Name] = string(content)
+	}
+	return files
+}
+
+// BenchmarkExcelReader compares the peak heap usage of reading a large
+// sheet row by row against loading it all at once with GetRows.
+func BenchmarkExcelReader(b *testing.B) {
+	const rows = 100000
+
+	f := excelize.NewFile()
+	sw, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		b.Fatal(err)
+	}
+	row := make([]interface{}, 10)
+	for i := 1; i <= rows; i++ {
+		for j := range row {
+			row[j] = fmt.Sprintf("row %d column %d", i, j)
+		}
+		err = sw.SetRow(getCellId(i, 1), row)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	err = sw.Flush()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	b.Run("Rows", func(subB *testing.B) {
+		benchmarkPeakHeap(subB, func() {
+			r := NewExcelReader(bytes.NewReader(data))
+			for {
+				_, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					subB.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("GetRows", func(subB *testing.B) {
+		benchmarkPeakHeap(subB, func() {
+			f, err := excelize.OpenReader(bytes.NewReader(data))
+			if err != nil {
+				subB.Fatal(err)
+			}
+			defer f.Close()
+
+			rows, err := f.GetRows("Sheet1")
+			if err != nil {
+				subB.Fatal(err)
+			}
+			for _, row := range rows {
+				_ = strings.Join(row, ",")
+			}
+		})
+	})
+}
+
+func benchmarkPeakHeap(b *testing.B, fn func()) {
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+
+		var (
+			wg   sync.WaitGroup
+			stop = make(chan struct{})
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ticker := time.NewTicker(time.Millisecond)
+			defer ticker.Stop()
+
+			var stats runtime.MemStats
+			for {
+				runtime.ReadMemStats(&stats)
+				if stats.HeapInuse > peak {
+					peak = stats.HeapInuse
+				}
+
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+
+		fn()
+
+		close(stop)
+		wg.Wait()
+	}
+	b.ReportMetric(float64(peak), "peak-heap-bytes")
+}
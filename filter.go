@@ -0,0 +1,627 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Expr is a filter expression over the named columns of a record.
+//
+// Expressions compare columns against literals, or other columns, and
+// combine comparisons with and, or and not, e.g.
+//
+//	age >= 21 and (state in ('CA', 'NY') or name =~ '^J') and not email is null
+//
+// Columns are referred to by name. Names which aren't plain identifiers
+// can be quoted with backticks. String literals are quoted with single
+// or double quotes.
+//
+// The supported operators are =, ==, !=, <>, <, <=, >, >=, =~ and !~
+// (regular expression match), in and not in, and is null and is not null.
+// Comparisons are numeric if both sides are numbers and lexical otherwise.
+// An empty field is considered null, as is the field set by NullField.
+// As in SQL, a comparison with a null operand never matches, including
+// in and not in, so nulls are only matched by is null.
+//
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// ExprOption
+type ExprOption func(*parser)
+
+// NullField sets a field which is null besides an empty one, e.g.
+// the NullString of the SQLReader whose records are filtered.
+func NullField(field string) ExprOption {
+	return func(p *parser) {
+		p.null = field
+	}
+}
+
+// ParseExpr parses a filter expression, see Expr.
+func ParseExpr(s string, opts ...ExprOption) (*Expr, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+	for _, opt := range opts {
+		opt(p)
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+
+	return &Expr{src: s, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Where drops every record for which the expression is false.
+func Where(e *Expr) Transform {
+	return func(cols []Column) ([]Column, func([]Value) []Value, error) {
+		match, err := e.root.bind(cols)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return cols, func(record []Value) []Value {
+			if !match(stringify(record)) {
+				return nil
+			}
+			return record
+		}, nil
+	}
+}
+
+// NewFilterReader returns a Reader which only reads the records of r for
+// which the expression is true. Columns are resolved in the same way
+// as a TransformReader.
+func NewFilterReader(r Reader, e *Expr) *TransformReader {
+	return NewTransformReader(r, Where(e))
+}
+
+type exprNode interface {
+	bind(cols []Column) (func(record []string) bool, error)
+}
+
+type operand interface {
+	bind(cols []Column) (func(record []string) string, error)
+}
+
+type columnRef string
+
+func (c columnRef) bind(cols []Column) (func([]string) string, error) {
+	idx, err := columnIndex(cols, string(c))
+	if err != nil {
+		return nil, err
+	}
+	return func(record []string) string {
+		if idx < len(record) {
+			return record[idx]
+		}
+		return ""
+	}, nil
+}
+
+type literal string
+
+func (l literal) bind([]Column) (func([]string) string, error) {
+	return func([]string) string { return string(l) }, nil
+}
+
+type logicalExpr struct {
+	and         bool
+	left, right exprNode
+}
+
+func (e logicalExpr) bind(cols []Column) (func([]string) bool, error) {
+	left, err := e.left.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.and {
+		return func(record []string) bool { return left(record) && right(record) }, nil
+	}
+	return func(record []string) bool { return left(record) || right(record) }, nil
+}
+
+type notExpr struct {
+	expr exprNode
+}
+
+func (e notExpr) bind(cols []Column) (func([]string) bool, error) {
+	match, err := e.expr.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+	return func(record []string) bool { return !match(record) }, nil
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+	null        string
+}
+
+func (e compareExpr) bind(cols []Column) (func([]string) bool, error) {
+	left, err := e.left.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+
+	var test func(int) bool
+	switch e.op {
+	case "=", "==":
+		test = func(c int) bool { return c == 0 }
+	case "!=", "<>":
+		test = func(c int) bool { return c != 0 }
+	case "<":
+		test = func(c int) bool { return c < 0 }
+	case "<=":
+		test = func(c int) bool { return c <= 0 }
+	case ">":
+		test = func(c int) bool { return c > 0 }
+	case ">=":
+		test = func(c int) bool { return c >= 0 }
+	default:
+		return nil, fmt.Errorf("tblconv: unknown operator: %s", e.op)
+	}
+
+	return func(record []string) bool {
+		a, b := left(record), right(record)
+		if isNull(a, e.null) || isNull(b, e.null) {
+			return false
+		}
+		return test(compare(a, b))
+	}, nil
+}
+
+// compare compares a and b numerically if both are numbers,
+// and lexically otherwise.
+func compare(a, b string) int {
+	x, xerr := strconv.ParseFloat(a, 64)
+	y, yerr := strconv.ParseFloat(b, 64)
+	if xerr != nil || yerr != nil {
+		return strings.Compare(a, b)
+	}
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type matchExpr struct {
+	left operand
+	re   *regexp.Regexp
+}
+
+func (e matchExpr) bind(cols []Column) (func([]string) bool, error) {
+	left, err := e.left.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+	return func(record []string) bool { return e.re.MatchString(left(record)) }, nil
+}
+
+type inExpr struct {
+	left   operand
+	list   []operand
+	negate bool
+	null   string
+}
+
+func (e inExpr) bind(cols []Column) (func([]string) bool, error) {
+	left, err := e.left.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]func([]string) string, len(e.list))
+	for i, item := range e.list {
+		list[i], err = item.bind(cols)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(record []string) bool {
+		val := left(record)
+		if isNull(val, e.null) {
+			return false
+		}
+		for _, item := range list {
+			v := item(record)
+			if !isNull(v, e.null) && compare(val, v) == 0 {
+				return !e.negate
+			}
+		}
+		return e.negate
+	}, nil
+}
+
+type isNullExpr struct {
+	left operand
+	null string
+}
+
+func (e isNullExpr) bind(cols []Column) (func([]string) bool, error) {
+	left, err := e.left.bind(cols)
+	if err != nil {
+		return nil, err
+	}
+	return func(record []string) bool { return isNull(left(record), e.null) }, nil
+}
+
+// isNull reports whether val is null, i.e. empty or the NullField.
+func isNull(val, null string) bool {
+	return val == "" || (null != "" && val == null)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokKeyword
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.val)
+	default:
+		return fmt.Sprintf("%q", t.val)
+	}
+}
+
+var keywords = map[string]bool{
+	"and":  true,
+	"or":   true,
+	"not":  true,
+	"in":   true,
+	"is":   true,
+	"null": true,
+}
+
+func lex(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case c == '\'' || c == '"' || c == '`':
+			val, n, err := lexQuoted(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("tblconv: invalid expression at offset %d: %w", i, err)
+			}
+
+			kind := tokString
+			if c == '`' {
+				kind = tokIdent
+			}
+			toks = append(toks, token{kind: kind, val: val, pos: i})
+			i += n
+		case c == '-' || c == '.' || isDigit(c):
+			j := i + 1
+			for j < len(s) && (s[j] == '.' || isDigit(rune(s[j])) || s[j] == 'e' || s[j] == 'E' ||
+				((s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+				j++
+			}
+			if _, err := strconv.ParseFloat(s[i:j], 64); err != nil {
+				return nil, fmt.Errorf("tblconv: invalid expression at offset %d: invalid number: %s", i, s[i:j])
+			}
+			toks = append(toks, token{kind: tokNumber, val: s[i:j], pos: i})
+			i = j
+		case c == '_' || unicode.IsLetter(c):
+			j := i + size
+			for j < len(s) {
+				r, n := utf8.DecodeRuneInString(s[j:])
+				if r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += n
+			}
+
+			word := s[i:j]
+			if keywords[strings.ToLower(word)] {
+				toks = append(toks, token{kind: tokKeyword, val: strings.ToLower(word), pos: i})
+			} else {
+				toks = append(toks, token{kind: tokIdent, val: word, pos: i})
+			}
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<>", "<=", ">=", "=~", "!~", "=", "<", ">", "(", ")", ","} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("tblconv: invalid expression at offset %d: unexpected %q", i, c)
+			}
+			toks = append(toks, token{kind: tokOp, val: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lexQuoted reads a quoted string from the start of s, returning its
+// unquoted value and the number of bytes consumed. The quote character
+// can be escaped with a backslash.
+func lexQuoted(s string) (string, int, error) {
+	quote := s[0]
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] != quote && s[i] != '\\' {
+					b.WriteByte('\\')
+				}
+			}
+			b.WriteByte(s[i])
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c", quote)
+}
+
+type parser struct {
+	toks []token
+	pos  int
+
+	// null is a field which is null besides an empty one
+	null string
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) accept(kind tokenKind, val string) bool {
+	tok := p.peek()
+	if tok.kind == kind && tok.val == val {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, val string) error {
+	if !p.accept(kind, val) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q, found %s", val, tok)
+	}
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("tblconv: invalid expression at offset %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(tokKeyword, "or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(tokKeyword, "and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (exprNode, error) {
+	if p.accept(tokKeyword, "not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	}
+
+	if p.accept(tokOp, "(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(tokOp, ")")
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (exprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.next()
+	switch {
+	case tok.kind == tokKeyword && tok.val == "is":
+		negate := p.accept(tokKeyword, "not")
+		err = p.expect(tokKeyword, "null")
+		if err != nil {
+			return nil, err
+		}
+
+		var expr exprNode = isNullExpr{left: left, null: p.null}
+		if negate {
+			expr = notExpr{expr: expr}
+		}
+		return expr, nil
+	case tok.kind == tokKeyword && tok.val == "not":
+		err = p.expect(tokKeyword, "in")
+		if err != nil {
+			return nil, err
+		}
+
+		return p.parseList(left, true)
+	case tok.kind == tokKeyword && tok.val == "in":
+		return p.parseList(left, false)
+	case tok.kind == tokOp && (tok.val == "=~" || tok.val == "!~"):
+		pattern := p.next()
+		if pattern.kind != tokString {
+			return nil, p.errorf(pattern, "expected a quoted regular expression, found %s", pattern)
+		}
+
+		re, err := regexp.Compile(pattern.val)
+		if err != nil {
+			return nil, p.errorf(pattern, "%s", err)
+		}
+
+		var expr exprNode = matchExpr{left: left, re: re}
+		if tok.val == "!~" {
+			expr = notExpr{expr: expr}
+		}
+		return expr, nil
+	case tok.kind == tokOp && tok.val != "(" && tok.val != ")" && tok.val != ",":
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareExpr{op: tok.val, left: left, right: right, null: p.null}, nil
+	default:
+		return nil, p.errorf(tok, "expected an operator, found %s", tok)
+	}
+}
+
+func (p *parser) parseList(left operand, negate bool) (exprNode, error) {
+	err := p.expect(tokOp, "(")
+	if err != nil {
+		return nil, err
+	}
+
+	var list []operand
+	for {
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+
+		if !p.accept(tokOp, ",") {
+			break
+		}
+	}
+
+	err = p.expect(tokOp, ")")
+	if err != nil {
+		return nil, err
+	}
+	return inExpr{left: left, list: list, negate: negate, null: p.null}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokIdent:
+		return columnRef(tok.val), nil
+	case tokString, tokNumber:
+		return literal(tok.val), nil
+	default:
+		return nil, p.errorf(tok, "expected a column or literal, found %s", tok)
+	}
+}
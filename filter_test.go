@@ -0,0 +1,233 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFilterReader(t *testing.T) {
+	header := []string{"id", "name", "age", "state", "email", "café"}
+	records := [][]string{
+		{"0", "tony", "48", "NY", "tony@stark.com", "latte"},
+		{"1", "peter", "17", "NY", "", `\N`},
+		{"2", "natasha", "35", "", "nat@shield.gov", ""},
+		{"3", "james", "9", "CA", "rhodey@af.mil", "mocha"},
+	}
+
+	testCases := []struct {
+		Name     string
+		Expr     string
+		Opts     []ExprOption
+		Expected []string
+	}{
+		{
+			Name:     "NumericComparison",
+			Expr:     "age >= 18",
+			Expected: []string{"0", "2"},
+		},
+		{
+			Name:     "NumericNotLexical",
+			Expr:     "age > 10",
+			Expected: []string{"0", "1", "2"},
+		},
+		{
+			Name:     "StringEquality",
+			Expr:     "state = 'NY'",
+			Expected: []string{"0", "1"},
+		},
+		{
+			Name:     "AndOrNot",
+			Expr:     "not (state == \"NY\" or state <> 'CA') AND age < 10",
+			Expected: []string{"3"},
+		},
+		{
+			Name:     "In",
+			Expr:     "id in (1, 3)",
+			Expected: []string{"1", "3"},
+		},
+		{
+			Name:     "NotIn",
+			Expr:     "name not in ('tony', 'peter')",
+			Expected: []string{"2", "3"},
+		},
+		{
+			Name:     "Regexp",
+			Expr:     "email =~ '\\.(com|gov)$'",
+			Expected: []string{"0", "2"},
+		},
+		{
+			Name:     "NotRegexp",
+			Expr:     "name !~ '^t'",
+			Expected: []string{"1", "2", "3"},
+		},
+		{
+			Name:     "IsNull",
+			Expr:     "state is null or email IS NULL",
+			Expected: []string{"1", "2"},
+		},
+		{
+			Name:     "IsNotNull",
+			Expr:     "`email` is not null and `state` is not null",
+			Expected: []string{"0", "3"},
+		},
+		{
+			Name:     "NullField",
+			Expr:     "café is null",
+			Opts:     []ExprOption{NullField(`\N`)},
+			Expected: []string{"1", "2"},
+		},
+		{
+			Name:     "NonASCIIColumn",
+			Expr:     "café in ('latte', 'mocha') or café is null",
+			Expected: []string{"0", "2", "3"},
+		},
+		{
+			Name:     "ColumnComparison",
+			Expr:     "id < age",
+			Expected: []string{"0", "1", "2", "3"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			expr, err := ParseExpr(testCase.Expr, testCase.Opts...)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			r := NewFilterReader(NewRecordsReader(append([][]string{header}, records...)...), expr)
+
+			got, err := r.Read()
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+			if len(got) != len(header) || got[0] != header[0] {
+				subT.Logf("expected header: %v\ngot: %v", header, got)
+				subT.Fail()
+				return
+			}
+
+			var ids []string
+			for {
+				record, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					subT.Error(err)
+					return
+				}
+				ids = append(ids, record[0])
+			}
+
+			if len(ids) != len(testCase.Expected) {
+				subT.Logf("expected: %v\ngot: %v", testCase.Expected, ids)
+				subT.Fail()
+				return
+			}
+			for i := range ids {
+				if ids[i] != testCase.Expected[i] {
+					subT.Logf("expected: %v\ngot: %v", testCase.Expected, ids)
+					subT.Fail()
+					return
+				}
+			}
+		})
+	}
+
+	t.Run("NullOperands", func(subT *testing.T) {
+		records := [][]string{
+			{"name", "age"},
+			{"ann", ""},
+			{"bob", "30"},
+			{"cy", "17"},
+			{"dee", "N/A"},
+		}
+
+		testCases := []struct {
+			Expr     string
+			Expected []string
+		}{
+			{Expr: "age < 21", Expected: []string{"cy"}},
+			{Expr: "age >= 0", Expected: []string{"bob", "cy"}},
+			{Expr: "age != 30", Expected: []string{"cy"}},
+			{Expr: "age in (30, 17)", Expected: []string{"bob", "cy"}},
+			{Expr: "age not in (30)", Expected: []string{"cy"}},
+			{Expr: "age = ''", Expected: nil},
+		}
+
+		for _, testCase := range testCases {
+			expr, err := ParseExpr(testCase.Expr, NullField("N/A"))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			w := NewRecordsWriter()
+			err = Copy(w, NewFilterReader(NewRecordsReader(records...), expr))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			var names []string
+			for _, record := range w.Records()[1:] {
+				names = append(names, record[0])
+			}
+			if strings.Join(names, ",") != strings.Join(testCase.Expected, ",") {
+				subT.Logf("%s\nexpected: %v\ngot: %v", testCase.Expr, testCase.Expected, names)
+				subT.Fail()
+			}
+		}
+	})
+
+	t.Run("InvalidExpr", func(subT *testing.T) {
+		for _, s := range []string{"", "age >", "age = 'x", "(age = 1", "age ~ 1", "name =~ '('", "age in ()"} {
+			_, err := ParseExpr(s)
+			if err == nil {
+				subT.Logf("expected an error parsing: %q", s)
+				subT.Fail()
+			}
+		}
+	})
+
+	t.Run("UnknownColumn", func(subT *testing.T) {
+		expr, err := ParseExpr("height > 6")
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		_, err = NewFilterReader(NewRecordsReader(append([][]string{header}, records...)...), expr).Read()
+		if err == nil {
+			subT.Log("expected an error for an unknown column")
+			subT.Fail()
+			return
+		}
+	})
+}
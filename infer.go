@@ -0,0 +1,393 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type inferConfig struct {
+	sampleSize  int
+	dateLayouts []string
+	timeLayouts []string
+	nullTokens  []string
+}
+
+// InferOption
+type InferOption func(*inferConfig)
+
+// SampleSize sets the number of records used to infer column types.
+// It defaults to 100.
+func SampleSize(n int) InferOption {
+	return func(cfg *inferConfig) {
+		cfg.sampleSize = n
+	}
+}
+
+// DateLayouts sets the layouts, as understood by time.Parse, recognized
+// as dates. It defaults to "2006-01-02".
+func DateLayouts(layouts ...string) InferOption {
+	return func(cfg *inferConfig) {
+		cfg.dateLayouts = layouts
+	}
+}
+
+// TimestampLayouts sets the layouts, as understood by time.Parse,
+// recognized as timestamps. It defaults to RFC 3339 and the common
+// SQL timestamp formats, e.g. "2006-01-02 15:04:05".
+func TimestampLayouts(layouts ...string) InferOption {
+	return func(cfg *inferConfig) {
+		cfg.timeLayouts = layouts
+	}
+}
+
+// NullTokens sets the fields which are read as NULL, ignoring case.
+// It defaults to only the empty string.
+func NullTokens(tokens ...string) InferOption {
+	return func(cfg *inferConfig) {
+		cfg.nullTokens = tokens
+	}
+}
+
+// InferReader infers the Kind of every column of an untyped Reader by
+// sampling its first records, and reads them as typed values.
+//
+// Columns are classified as IntKind, DecimalKind, BoolKind, DateKind,
+// TimeKind or StringKind, whichever is the most specific kind every
+// sampled field, other than NULLs, can be parsed as. A column is nullable
+// if a NULL was sampled from it. Numbers with leading zeros, e.g. zip
+// codes, are not numbers but strings.
+//
+// Column names are taken from the schema of the underlying Reader. If it
+// does not provide one, its first record is taken to be the header row.
+// Either way, the header is only available through Schema and is never
+// returned from Read.
+//
+// Records past the sample which can't be parsed as the inferred kinds
+// are returned from ReadTyped as a *RecordError.
+//
+type InferReader struct {
+	r     Reader
+	cfg   inferConfig
+	nulls map[string]bool
+
+	columns []Column
+	kinds   []kindSet
+	buf     []sampledRecord
+	sampled bool
+}
+
+type sampledRecord struct {
+	record []string
+	err    error
+}
+
+// NewInferReader
+func NewInferReader(r Reader, opts ...InferOption) *InferReader {
+	cfg := inferConfig{
+		sampleSize:  100,
+		dateLayouts: []string{dateLayout},
+		timeLayouts: timeLayouts[:len(timeLayouts)-1],
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	nulls := map[string]bool{"": true}
+	for _, token := range cfg.nullTokens {
+		nulls[strings.ToLower(token)] = true
+	}
+
+	return &InferReader{
+		r:     r,
+		cfg:   cfg,
+		nulls: nulls,
+	}
+}
+
+// Read
+func (r *InferReader) Read() ([]string, error) {
+	return r.ReadContext(context.Background())
+}
+
+// ReadContext
+func (r *InferReader) ReadContext(ctx context.Context) ([]string, error) {
+	if !r.sampled {
+		err := r.sample(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(r.buf) > 0 {
+		s := r.buf[0]
+		r.buf = r.buf[1:]
+		return s.record, s.err
+	}
+	return readFunc(r.r)(ctx)
+}
+
+// ReadTyped
+func (r *InferReader) ReadTyped() ([]Value, error) {
+	return r.ReadTypedContext(context.Background())
+}
+
+// ReadTypedContext
+func (r *InferReader) ReadTypedContext(ctx context.Context) ([]Value, error) {
+	record, err := r.ReadContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]Value, len(record))
+	for i, field := range record {
+		if i >= len(r.columns) {
+			vals[i] = StringValue(field)
+			continue
+		}
+
+		col := r.columns[i]
+		vals[i], err = r.parse(field, col.Kind)
+		if err != nil {
+			return nil, &RecordError{
+				Record: record,
+				Err:    fmt.Errorf("tblconv: column %s: %w", col.Name, err),
+			}
+		}
+	}
+	return vals, nil
+}
+
+// Schema
+func (r *InferReader) Schema() ([]Column, error) {
+	return r.SchemaContext(context.Background())
+}
+
+// SchemaContext returns the columns of the underlying Reader with
+// their inferred kinds.
+func (r *InferReader) SchemaContext(ctx context.Context) ([]Column, error) {
+	if !r.sampled {
+		err := r.sample(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r.columns, nil
+}
+
+// Abort aborts the underlying Reader, if it implements Aborter.
+func (r *InferReader) Abort() error {
+	if a, ok := r.r.(Aborter); ok {
+		return a.Abort()
+	}
+	return nil
+}
+
+// sample buffers the first records of the underlying Reader and infers
+// the kind of each column from them. It can be resumed after returning
+// an error.
+func (r *InferReader) sample(ctx context.Context) error {
+	read := readFunc(r.r)
+
+	if r.columns == nil {
+		columns, err := readSchema(ctx, r.r)
+		if err == ErrNoSchema {
+			var header []string
+			header, err = read(ctx)
+			columns = columnsNamed(header)
+		}
+		if err != nil {
+			return err
+		}
+
+		r.columns = make([]Column, len(columns))
+		copy(r.columns, columns)
+		r.kinds = make([]kindSet, len(columns))
+		for i := range r.kinds {
+			r.kinds[i] = allKinds
+		}
+	}
+
+	for len(r.buf) < r.cfg.sampleSize {
+		record, err := read(ctx)
+		if err == io.EOF {
+			break
+		}
+
+		var recErr *RecordError
+		if errors.As(err, &recErr) {
+			r.buf = append(r.buf, sampledRecord{record: record, err: err})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		r.buf = append(r.buf, sampledRecord{record: record})
+		for i, field := range record {
+			if i < len(r.kinds) {
+				r.observe(i, field)
+			}
+		}
+	}
+
+	for i, kinds := range r.kinds {
+		r.columns[i].Kind = kinds.kind()
+		r.columns[i].DatabaseType = ""
+		r.columns[i].Nullable = kinds&nullSeen != 0
+	}
+	r.sampled = true
+	return nil
+}
+
+func (r *InferReader) observe(i int, field string) {
+	if r.isNull(field) {
+		r.kinds[i] |= nullSeen
+		return
+	}
+
+	for _, k := range inferredKinds {
+		if r.kinds[i].has(k) && !r.is(field, k) {
+			r.kinds[i] &^= kindBit(k)
+		}
+	}
+}
+
+func (r *InferReader) isNull(field string) bool {
+	return r.nulls[strings.ToLower(field)]
+}
+
+var decimalPattern = regexp.MustCompile(`^[-+]?(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?$`)
+
+func (r *InferReader) is(field string, k Kind) bool {
+	_, err := r.parse(field, k)
+	return err == nil
+}
+
+// parse parses field as Kind k, using the configured layouts and
+// null tokens.
+func (r *InferReader) parse(field string, k Kind) (Value, error) {
+	if r.isNull(field) {
+		return NullValue(k), nil
+	}
+
+	switch k {
+	case IntKind:
+		if hasLeadingZero(field) {
+			return Value{}, fmt.Errorf("invalid integer: %q", field)
+		}
+		return ParseValue(field, IntKind)
+	case DecimalKind:
+		if !decimalPattern.MatchString(field) || hasLeadingZero(field) {
+			return Value{}, fmt.Errorf("invalid decimal: %q", field)
+		}
+		return DecimalValue(field), nil
+	case BoolKind:
+		switch strings.ToLower(field) {
+		case "true", "t", "yes", "y":
+			return BoolValue(true), nil
+		case "false", "f", "no", "n":
+			return BoolValue(false), nil
+		}
+		return Value{}, fmt.Errorf("invalid boolean: %q", field)
+	case DateKind:
+		t, err := parseLayouts(field, r.cfg.dateLayouts)
+		if err != nil {
+			return Value{}, err
+		}
+		return DateValue(t), nil
+	case TimeKind:
+		t, err := parseLayouts(field, r.cfg.timeLayouts)
+		if err != nil {
+			return Value{}, err
+		}
+		return TimeValue(t), nil
+	default:
+		return StringValue(field), nil
+	}
+}
+
+// hasLeadingZero reports whether a number has a leading zero, e.g. a zip
+// code like 02134, which is not a number but a string of digits.
+func hasLeadingZero(field string) bool {
+	field = strings.TrimLeft(field, "+-")
+	return len(field) > 1 && field[0] == '0' && field[1] >= '0' && field[1] <= '9'
+}
+
+func parseLayouts(s string, layouts []string) (t time.Time, err error) {
+	err = fmt.Errorf("invalid time: %q", s)
+	for _, layout := range layouts {
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return
+		}
+	}
+	return
+}
+
+// kindSet is the set of kinds a column may still be inferred as,
+// along with whether a NULL has been seen in it.
+type kindSet uint16
+
+// inferredKinds are the kinds InferReader can infer, from most
+// to least specific.
+var inferredKinds = []Kind{IntKind, DecimalKind, BoolKind, DateKind, TimeKind}
+
+const nullSeen kindSet = 1 << 15
+
+var allKinds = func() kindSet {
+	var s kindSet
+	for _, k := range inferredKinds {
+		s |= kindBit(k)
+	}
+	return s
+}()
+
+func kindBit(k Kind) kindSet {
+	return 1 << k
+}
+
+func (s kindSet) has(k Kind) bool {
+	return s&kindBit(k) != 0
+}
+
+// kind returns the most specific kind left in s. Columns which only
+// contained NULLs are strings.
+func (s kindSet) kind() Kind {
+	if s&allKinds == allKinds {
+		return StringKind
+	}
+	for _, k := range inferredKinds {
+		if s.has(k) {
+			return k
+		}
+	}
+	return StringKind
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferReader(t *testing.T) {
+	input := `id,price,active,born,seen,name,notes
+0,12.50,true,1970-05-29,2022-06-01 12:30:00,tony,
+1,3,no,1963-08-10,2022-06-01T12:30:00Z,peter,N/A
+2,,f,01/02/2006,,natasha,
+`
+
+	t.Run("Schema", func(subT *testing.T) {
+		r := NewInferReader(
+			NewCSVReader(strings.NewReader(input), CSVHeader(true)),
+			NullTokens("n/a"),
+			DateLayouts("2006-01-02", "01/02/2006"),
+		)
+
+		columns, err := r.Schema()
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		expected := []Column{
+			{Name: "id", Kind: IntKind},
+			{Name: "price", Kind: DecimalKind, Nullable: true},
+			{Name: "active", Kind: BoolKind},
+			{Name: "born", Kind: DateKind},
+			{Name: "seen", Kind: TimeKind, Nullable: true},
+			{Name: "name", Kind: StringKind},
+			{Name: "notes", Kind: StringKind, Nullable: true},
+		}
+		if len(expected) != len(columns) {
+			subT.Logf("expected: %v\ngot: %v", expected, columns)
+			subT.Fail()
+			return
+		}
+		for i := range expected {
+			if expected[i] != columns[i] {
+				subT.Logf("expected: %v\ngot: %v", expected[i], columns[i])
+				subT.Fail()
+			}
+		}
+
+		vals, err := r.ReadTyped()
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		seen := time.Date(2022, 6, 1, 12, 30, 0, 0, time.UTC)
+		expectedVals := []Value{
+			IntValue(0),
+			DecimalValue("12.50"),
+			BoolValue(true),
+			DateValue(time.Date(1970, 5, 29, 0, 0, 0, 0, time.UTC)),
+			TimeValue(seen),
+			StringValue("tony"),
+			NullValue(StringKind),
+		}
+		for i := range expectedVals {
+			if expectedVals[i].String() != vals[i].String() || expectedVals[i].Kind != vals[i].Kind || expectedVals[i].Null != vals[i].Null {
+				subT.Logf("expected: %v\ngot: %v", expectedVals[i], vals[i])
+				subT.Fail()
+			}
+		}
+
+		record, err := r.Read()
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+		if record[0] != "1" || record[6] != "N/A" {
+			subT.Logf("expected the raw second record\ngot: %v", record)
+			subT.Fail()
+		}
+	})
+
+	t.Run("HeaderFromFirstRecord", func(subT *testing.T) {
+		r := NewInferReader(NewRecordsReader([]string{"id", "name"}, []string{"0", "tony"}))
+
+		columns, err := r.Schema()
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+		if columns[0].Name != "id" || columns[0].Kind != IntKind || columns[1].Kind != StringKind {
+			subT.Logf("unexpected schema: %v", columns)
+			subT.Fail()
+			return
+		}
+
+		w := NewRecordsWriter()
+		err = Copy(w, r)
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+		if records := w.Records(); len(records) != 1 || records[0][1] != "tony" {
+			subT.Logf("expected only the data record\ngot: %v", records)
+			subT.Fail()
+		}
+	})
+
+	t.Run("LeadingZeros", func(subT *testing.T) {
+		r := NewInferReader(NewRecordsReader(
+			[]string{"zip", "code", "id", "ratio"},
+			[]string{"02134", "007.5", "0", "0.5"},
+			[]string{"10001", "1.5", "-12", "-0.25"},
+		))
+
+		columns, err := r.Schema()
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		expected := []Kind{StringKind, StringKind, IntKind, DecimalKind}
+		for i, k := range expected {
+			if columns[i].Kind != k {
+				subT.Logf("expected: %v\ngot: %v", k, columns[i])
+				subT.Fail()
+			}
+		}
+	})
+
+	t.Run("InvalidPastSample", func(subT *testing.T) {
+		r := NewInferReader(NewRecordsReader([]string{"id"}, []string{"0"}, []string{"one"}), SampleSize(1))
+
+		_, err := r.ReadTyped()
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		_, err = r.ReadTyped()
+		var recErr *RecordError
+		if !errors.As(err, &recErr) {
+			subT.Logf("expected a *RecordError\ngot: %v", err)
+			subT.Fail()
+		}
+	})
+}
@@ -0,0 +1,388 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ODSReader reads the sheets of an OpenDocument spreadsheet (.ods), as
+// saved by LibreOffice. It accepts the same options as an ExcelReader,
+// except for DefinedName, Formulas and FillMergedCells: formula cells
+// are always read as their cached values. Without SheetName, the first
+// sheet is read.
+//
+// Numbers are read without their number formats applied, except for
+// dates and times, which are read as "2006-01-02", "15:04:05" or
+// "2006-01-02 15:04:05". Booleans are read as "TRUE" or "FALSE".
+//
+// Rows are read one at a time from the document, though the document
+// itself is held in memory since it is a zip archive.
+type ODSReader struct {
+	sheetReader
+}
+
+// NewODSReader
+func NewODSReader(r io.Reader, opts ...ExcelOption) *ODSReader {
+	var cfg excelConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &ODSReader{
+		sheetReader: sheetReader{
+			cfg:  cfg,
+			open: func() (spreadsheet, error) { return openODS(r) },
+		},
+	}
+}
+
+const (
+	odsOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// these bound how far repeated rows, cells and spaces are expanded,
+// so a tiny document can't expand into gigabytes. They are the largest
+// sheet and the longest cell supported by spreadsheet applications.
+const (
+	odsMaxRows     = 1 << 20
+	odsMaxColumns  = 1 << 14
+	odsMaxCellText = 32767
+)
+
+type odsDocument struct {
+	content *zip.File
+	names   []string
+}
+
+func openODS(r io.Reader) (*odsDocument, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("tblconv: not an ods document: %w", err)
+	}
+
+	doc := &odsDocument{}
+	for _, f := range z.File {
+		if f.Name == "content.xml" {
+			doc.content = f
+			break
+		}
+	}
+	if doc.content == nil {
+		return nil, errors.New("tblconv: not an ods document: no content.xml")
+	}
+
+	rc, err := doc.content.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	d := xml.NewDecoder(rc)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return doc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok && isODS(start.Name, odsTableNS, "table") {
+			doc.names = append(doc.names, odsAttr(start, odsTableNS, "name"))
+			err = d.Skip()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (doc *odsDocument) sheetNames() []string {
+	return doc.names
+}
+
+func (doc *odsDocument) rows(sheet string) (sheetRows, error) {
+	rc, err := doc.content.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	d := xml.NewDecoder(rc)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			rc.Close()
+			if err == io.EOF {
+				err = fmt.Errorf("tblconv: no sheet named %s", sheet)
+			}
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || !isODS(start.Name, odsTableNS, "table") {
+			continue
+		}
+		if odsAttr(start, odsTableNS, "name") == sheet {
+			return &odsRows{rc: rc, d: d}, nil
+		}
+		err = d.Skip()
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+	}
+}
+
+func (doc *odsDocument) close() error {
+	return nil
+}
+
+// odsRows reads the rows of a table:table element.
+type odsRows struct {
+	rc  io.ReadCloser
+	d   *xml.Decoder
+	num int
+
+	// a row repeated with table:number-rows-repeated
+	row    []string
+	repeat int
+}
+
+func (r *odsRows) next() (int, []string, bool, error) {
+	for {
+		if r.num >= odsMaxRows {
+			return 0, nil, false, nil
+		}
+		if r.repeat > 0 {
+			r.repeat--
+			r.num++
+			return r.num, r.row, true, nil
+		}
+
+		tok, err := r.d.Token()
+		if err != nil {
+			return 0, nil, false, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			if isODS(tok.Name, odsTableNS, "table") {
+				return 0, nil, false, nil
+			}
+		case xml.StartElement:
+			if !isODS(tok.Name, odsTableNS, "table-row") {
+				continue
+			}
+
+			repeat := odsRepeat(tok, "number-rows-repeated", odsMaxRows)
+			row, err := r.readRow()
+			if err != nil {
+				return 0, nil, false, err
+			}
+			if len(row) == 0 {
+				r.num += repeat
+				continue
+			}
+			r.row = row
+			r.repeat = repeat
+		}
+	}
+}
+
+// readRow reads the cells of a table:table-row element, leaving
+// out trailing empty cells and cells past odsMaxColumns.
+func (r *odsRows) readRow() ([]string, error) {
+	var row []string
+	empty := 0
+	for {
+		tok, err := r.d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			return row, nil
+		case xml.StartElement:
+			if !isODS(tok.Name, odsTableNS, "table-cell") && !isODS(tok.Name, odsTableNS, "covered-table-cell") {
+				err = r.d.Skip()
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			repeat := odsRepeat(tok, "number-columns-repeated", odsMaxColumns)
+			value, err := r.readCell(tok)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				empty += repeat
+				continue
+			}
+
+			for ; empty > 0 && len(row) < odsMaxColumns; empty-- {
+				row = append(row, "")
+			}
+			for ; repeat > 0 && len(row) < odsMaxColumns; repeat-- {
+				row = append(row, value)
+			}
+			empty = 0
+		}
+	}
+}
+
+// readCell reads the value of a table:table-cell element.
+func (r *odsRows) readCell(start xml.StartElement) (string, error) {
+	text, err := odsText(r.d)
+	if err != nil {
+		return "", err
+	}
+
+	switch odsAttr(start, odsOfficeNS, "value-type") {
+	case "float", "percentage", "currency":
+		return odsAttr(start, odsOfficeNS, "value"), nil
+	case "date":
+		value := odsAttr(start, odsOfficeNS, "date-value")
+		if t, err := time.Parse("2006-01-02T15:04:05", value); err == nil {
+			if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+				return t.Format("2006-01-02"), nil
+			}
+			return t.Format("2006-01-02 15:04:05"), nil
+		}
+		return value, nil
+	case "time":
+		value := odsAttr(start, odsOfficeNS, "time-value")
+		if d, err := time.ParseDuration(strings.ToLower(strings.TrimPrefix(value, "PT"))); err == nil {
+			return time.Time{}.Add(d).Format("15:04:05"), nil
+		}
+		return value, nil
+	case "boolean":
+		return strings.ToUpper(odsAttr(start, odsOfficeNS, "boolean-value")), nil
+	case "string":
+		if value := odsAttr(start, odsOfficeNS, "string-value"); value != "" {
+			return value, nil
+		}
+	}
+	return text, nil
+}
+
+// odsText reads the text of the element just started, joining
+// its paragraphs with newlines.
+func odsText(d *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	paragraphs := 0
+	depth := 1
+	for depth > 0 {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch tok := tok.(type) {
+		case xml.CharData:
+			if paragraphs > 0 {
+				sb.Write(tok)
+			}
+		case xml.EndElement:
+			depth--
+		case xml.StartElement:
+			depth++
+			switch {
+			case isODS(tok.Name, odsTextNS, "p"), isODS(tok.Name, odsTextNS, "h"):
+				if paragraphs > 0 {
+					sb.WriteByte('\n')
+				}
+				paragraphs++
+			case isODS(tok.Name, odsTextNS, "s"):
+				n, err := strconv.Atoi(odsAttr(tok, odsTextNS, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				if n > odsMaxCellText-sb.Len() {
+					n = odsMaxCellText - sb.Len()
+				}
+				if n > 0 {
+					sb.WriteString(strings.Repeat(" ", n))
+				}
+			case isODS(tok.Name, odsTextNS, "tab"):
+				sb.WriteByte('\t')
+			case isODS(tok.Name, odsTextNS, "line-break"):
+				sb.WriteByte('\n')
+			case isODS(tok.Name, odsOfficeNS, "annotation"):
+				err = d.Skip()
+				if err != nil {
+					return "", err
+				}
+				depth--
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+func (r *odsRows) close() error {
+	return r.rc.Close()
+}
+
+func isODS(name xml.Name, space, local string) bool {
+	return name.Space == space && name.Local == local
+}
+
+func odsAttr(start xml.StartElement, space, local string) string {
+	for _, attr := range start.Attr {
+		if isODS(attr.Name, space, local) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// odsRepeat returns the count of a repeated row or cell, up to limit.
+func odsRepeat(start xml.StartElement, attr string, limit int) int {
+	n, err := strconv.Atoi(odsAttr(start, odsTableNS, attr))
+	if err != nil || n < 1 {
+		return 1
+	}
+	if n > limit {
+		return limit
+	}
+	return n
+}
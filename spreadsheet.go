@@ -0,0 +1,351 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"fmt"
+	"io"
+)
+
+// spreadsheet is a workbook read by a sheetReader.
+type spreadsheet interface {
+	sheetNames() []string
+
+	// rows returns the rows of a sheet which hold any values.
+	rows(sheet string) (sheetRows, error)
+
+	close() error
+}
+
+type sheetRows interface {
+	// next returns the next row and its number, counting from 1. Rows
+	// without any values may be left out. ok is false once the sheet
+	// has no more rows.
+	next() (num int, row []string, ok bool, err error)
+
+	close() error
+}
+
+// definedNames is implemented by spreadsheets which support DefinedName.
+type definedNames interface {
+	// definedRange returns the sheet and cell range referred
+	// to by a defined name.
+	definedRange(name string) (sheet, ref string, err error)
+}
+
+// rowFiller is implemented by sheetRows which fill in the cells of
+// a row beyond its values, e.g. formulas or merged cells.
+type rowFiller interface {
+	// fill fills in the cells of the row with the given number,
+	// up to at least width cells.
+	fill(num int, row []string, width int) ([]string, error)
+}
+
+// sheetReader reads the rows of the sheets of a spreadsheet,
+// implementing the ExcelOptions shared by ExcelReader, XLSReader
+// and ODSReader.
+type sheetReader struct {
+	cfg  excelConfig
+	open func() (spreadsheet, error)
+
+	book        spreadsheet
+	sheets      []string
+	sheet       int
+	rng         cellRange
+	rows        sheetRows
+	rowNum      int
+	header      []string
+	foundHeader bool
+	done        bool
+
+	// ahead is the next row with values, read ahead of
+	// the empty rows preceding it.
+	ahead    []string
+	aheadNum int
+
+	// empty rows are only returned once a non-empty row follows them,
+	// so trailing empty rows are never read.
+	empty int
+	next  []string
+}
+
+// Read
+func (r *sheetReader) Read() ([]string, error) {
+	if r.rows == nil && !r.done {
+		err := r.load()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for r.next == nil {
+		if r.done {
+			return nil, io.EOF
+		}
+
+		row, ok, err := r.nextRow()
+		if err != nil {
+			return nil, err
+		}
+		if ok && isEmptyRow(row) && r.cfg.stopAtEmpty {
+			ok = false
+		}
+
+		if !ok {
+			if r.sheet+1 < len(r.sheets) {
+				err := r.openSheet(r.sheet + 1)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			err := r.close()
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+
+		if isEmptyRow(row) {
+			r.empty += 1
+			continue
+		}
+		r.next = row
+	}
+
+	if r.empty > 0 {
+		r.empty -= 1
+		return r.record([]string{}), nil
+	}
+
+	row := r.next
+	r.next = nil
+	return r.record(row), nil
+}
+
+// nextRow returns the next row of the current sheet within range,
+// including empty rows. ok is false once the sheet or range has no
+// more rows.
+func (r *sheetReader) nextRow() (row []string, ok bool, err error) {
+	for {
+		if r.ahead == nil {
+			r.aheadNum, r.ahead, ok, err = r.rows.next()
+			if err != nil || !ok {
+				return nil, false, err
+			}
+		}
+
+		r.rowNum += 1
+		if r.rng.bottom > 0 && r.rowNum > r.rng.bottom {
+			return nil, false, nil
+		}
+
+		row = []string{}
+		if r.aheadNum <= r.rowNum {
+			row = r.ahead
+			r.ahead = nil
+		}
+		if r.rowNum < r.rng.top {
+			continue
+		}
+
+		if f, ok := r.rows.(rowFiller); ok {
+			row, err = f.fill(r.rowNum, row, r.width())
+			if err != nil {
+				return nil, false, err
+			}
+		}
+		return r.rng.columns(row), true, nil
+	}
+}
+
+// width returns the number of cells of a row up to the
+// last column of the header or range.
+func (r *sheetReader) width() int {
+	left := 1
+	if r.rng.left > 1 {
+		left = r.rng.left
+	}
+
+	width := left - 1 + len(r.header)
+	if r.rng.right > width {
+		width = r.rng.right
+	}
+	return width
+}
+
+func (r *sheetReader) record(row []string) []string {
+	if r.cfg.sheetColumn == "" {
+		return row
+	}
+	return append([]string{r.sheets[r.sheet]}, row...)
+}
+
+// Schema returns the header row as untyped columns. ErrNoSchema is
+// returned if the reader was not configured with HeaderRow.
+func (r *sheetReader) Schema() ([]Column, error) {
+	if r.cfg.headerRow < 1 {
+		return nil, ErrNoSchema
+	}
+
+	if r.rows == nil && !r.done {
+		err := r.load()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !r.foundHeader {
+		return nil, io.EOF
+	}
+
+	header := r.header
+	if r.cfg.sheetColumn != "" {
+		header = append([]string{r.cfg.sheetColumn}, header...)
+	}
+	return columnsNamed(header), nil
+}
+
+// Abort closes the workbook.
+func (r *sheetReader) Abort() error {
+	return r.close()
+}
+
+func (r *sheetReader) load() error {
+	book, err := r.open()
+	if err != nil {
+		r.done = true
+		return err
+	}
+	r.book = book
+
+	ref := r.cfg.cellRange
+	if names, ok := book.(definedNames); ok && r.cfg.definedName != "" {
+		var sheet string
+		sheet, ref, err = names.definedRange(r.cfg.definedName)
+		if err != nil {
+			r.close()
+			return err
+		}
+		r.sheets = []string{sheet}
+	} else {
+		r.sheets, err = r.cfg.sheetsOf(book.sheetNames())
+		if err != nil {
+			r.close()
+			return err
+		}
+	}
+
+	r.rng, err = parseCellRange(ref)
+	if err != nil {
+		r.close()
+		return err
+	}
+	return r.openSheet(0)
+}
+
+// openSheet starts reading the i-th sheet, skipping every row up to
+// and including the header row, or the rows skipped by SkipRows.
+func (r *sheetReader) openSheet(i int) error {
+	if r.rows != nil {
+		err := r.rows.close()
+		if err != nil {
+			return err
+		}
+		r.rows = nil
+	}
+
+	if !containsString(r.book.sheetNames(), r.sheets[i]) {
+		r.close()
+		return fmt.Errorf("tblconv: no sheet named %s", r.sheets[i])
+	}
+	rows, err := r.book.rows(r.sheets[i])
+	if err != nil {
+		r.close()
+		return err
+	}
+	r.sheet = i
+	r.rows = rows
+	r.rowNum = 0
+	r.ahead = nil
+	r.empty = 0
+
+	for row := 1; row <= r.cfg.skipRows+r.cfg.headerRow; row++ {
+		header, ok, err := r.nextRow()
+		if err != nil || !ok {
+			return err
+		}
+
+		if i == 0 && row == r.cfg.skipRows+r.cfg.headerRow && r.cfg.headerRow > 0 {
+			r.header = header
+			r.foundHeader = true
+		}
+	}
+	return nil
+}
+
+func (r *sheetReader) close() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+
+	var err error
+	if r.rows != nil {
+		err = r.rows.close()
+	}
+	if r.book != nil {
+		if cerr := r.book.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// gridRows iterates over the rows of a sheet held in memory.
+type gridRows struct {
+	nums []int
+	rows map[int][]string
+}
+
+func (g *gridRows) next() (int, []string, bool, error) {
+	if len(g.nums) == 0 {
+		return 0, nil, false, nil
+	}
+	num := g.nums[0]
+	g.nums = g.nums[1:]
+	return num, g.rows[num], true, nil
+}
+
+func (g *gridRows) close() error {
+	return nil
+}
@@ -0,0 +1,528 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// biffCell is a cell record of a test .xls sheet.
+type biffCell struct {
+	typ  uint16
+	data []byte
+}
+
+type biffSheet struct {
+	name  string
+	cells []biffCell
+}
+
+func biffRec(typ uint16, data ...[]byte) []byte {
+	var b bytes.Buffer
+	for i, d := range data {
+		if i > 0 {
+			typ = biffContinue
+		}
+		binary.Write(&b, binary.LittleEndian, typ)
+		binary.Write(&b, binary.LittleEndian, uint16(len(d)))
+		b.Write(d)
+	}
+	return b.Bytes()
+}
+
+func le(vs ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, v := range vs {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+func sstCell(row, col uint16, i uint32) biffCell {
+	return biffCell{biffLabelSST, le(row, col, uint16(0), i)}
+}
+
+func numberCell(row, col, xf uint16, f float64) biffCell {
+	return biffCell{biffNumber, le(row, col, xf, math.Float64bits(f))}
+}
+
+func rkCell(row, col uint16, n int32) biffCell {
+	return biffCell{biffRK, le(row, col, uint16(0), uint32(n)<<2|0x02)}
+}
+
+// buildXLS builds a BIFF8 workbook in a compound file. Shared strings
+// are split over a CONTINUE record in the middle of their characters,
+// xf 1 is a date format and every other xf is general.
+func buildXLS(sheets []biffSheet, strings []string) []byte {
+	var sst bytes.Buffer
+	binary.Write(&sst, binary.LittleEndian, uint32(len(strings)))
+	binary.Write(&sst, binary.LittleEndian, uint32(len(strings)))
+	for _, s := range strings {
+		units := utf16.Encode([]rune(s))
+		binary.Write(&sst, binary.LittleEndian, uint16(len(units)))
+		sst.WriteByte(0x01)
+		binary.Write(&sst, binary.LittleEndian, units)
+	}
+	// split after the first character of the first string, where
+	// the CONTINUE record starts with compressed characters
+	raw := sst.Bytes()
+	var sstRec []byte
+	if len(strings) > 0 && len(strings[0]) > 1 {
+		head := raw[:8+3+2]
+		rest := []byte{0x00}
+		first := utf16.Encode([]rune(strings[0]))
+		for _, u := range first[1:] {
+			rest = append(rest, byte(u))
+		}
+		rest = append(rest, raw[8+3+2*len(first):]...)
+		sstRec = biffRec(biffSST, head, rest)
+	} else {
+		sstRec = biffRec(biffSST, raw)
+	}
+
+	bof := func(dt uint16) []byte {
+		return biffRec(biffBOF, le(uint16(0x0600), dt, uint16(0), uint16(0), uint32(0), uint32(0)))
+	}
+
+	var globals bytes.Buffer
+	globals.Write(bof(0x0005))
+	globals.Write(biffRec(biffXF, le(uint16(0), uint16(0), make([]byte, 16))))
+	globals.Write(biffRec(biffXF, le(uint16(0), uint16(14), make([]byte, 16))))
+	boundSheets := globals.Len()
+	for _, sheet := range sheets {
+		globals.Write(biffRec(biffBoundSheet, le(uint32(0), uint8(0), uint8(0), uint8(len(sheet.name)), uint8(0), []byte(sheet.name))))
+	}
+	globals.Write(sstRec)
+	globals.Write(biffRec(biffEOF, nil))
+
+	stream := globals.Bytes()
+	off := boundSheets
+	for _, sheet := range sheets {
+		binary.LittleEndian.PutUint32(stream[off+4:], uint32(len(stream)))
+		off += 4 + 8 + len(sheet.name)
+
+		stream = append(stream, bof(0x0010)...)
+		for _, c := range sheet.cells {
+			stream = append(stream, biffRec(c.typ, c.data)...)
+		}
+		stream = append(stream, biffRec(biffEOF, nil)...)
+	}
+	return buildCFB("Workbook", stream)
+}
+
+// buildCFB builds a compound file with a single stream, which is
+// padded with zeros so it is not stored in the mini stream.
+func buildCFB(name string, stream []byte) []byte {
+	const (
+		sectorSize = 512
+		freeSect   = 0xffffffff
+		endOfChain = 0xfffffffe
+		fatSect    = 0xfffffffd
+		noStream   = 0xffffffff
+	)
+
+	if len(stream) < 4096 {
+		stream = append(stream, make([]byte, 4096-len(stream))...)
+	}
+	if r := len(stream) % sectorSize; r > 0 {
+		stream = append(stream, make([]byte, sectorSize-r)...)
+	}
+	n := len(stream) / sectorSize
+
+	var b bytes.Buffer
+	b.Write([]byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1})
+	b.Write(make([]byte, 16))
+	b.Write(le(uint16(0x3e), uint16(3), uint16(0xfffe), uint16(9), uint16(6)))
+	b.Write(make([]byte, 6))
+	b.Write(le(uint32(0), uint32(1), uint32(1), uint32(0), uint32(4096), uint32(endOfChain), uint32(0), uint32(endOfChain), uint32(0)))
+	b.Write(le(uint32(0)))
+	for i := 1; i < 109; i++ {
+		b.Write(le(uint32(freeSect)))
+	}
+
+	// sector 0 is the FAT, sector 1 the directory
+	// and the stream starts at sector 2
+	fat := make([]uint32, sectorSize/4)
+	for i := range fat {
+		fat[i] = freeSect
+	}
+	fat[0] = fatSect
+	fat[1] = endOfChain
+	for i := 0; i < n; i++ {
+		fat[2+i] = uint32(3 + i)
+	}
+	fat[1+n] = endOfChain
+	b.Write(le(fat))
+
+	entry := func(name string, typ uint8, child, start uint32, size uint64) []byte {
+		units := utf16.Encode([]rune(name))
+		raw := make([]byte, 64)
+		for i, u := range units {
+			binary.LittleEndian.PutUint16(raw[2*i:], u)
+		}
+		return le(raw, uint16(2*len(units)+2), typ, uint8(1), uint32(noStream), uint32(noStream), child, make([]byte, 16), uint32(0), uint64(0), uint64(0), start, size)
+	}
+	b.Write(entry("Root Entry", 5, 1, endOfChain, 0))
+	b.Write(entry(name, 2, noStream, 2, uint64(len(stream))))
+	for i := 0; i < 2; i++ {
+		b.Write(entry("", 0, noStream, 0, 0))
+	}
+
+	b.Write(stream)
+	return b.Bytes()
+}
+
+type odsSheet struct {
+	name string
+	rows string
+}
+
+// buildODS builds an OpenDocument spreadsheet with the given
+// table:table-row elements per sheet.
+func buildODS(sheets []odsSheet) []byte {
+	var b bytes.Buffer
+	z := zip.NewWriter(&b)
+
+	w, _ := z.Create("mimetype")
+	io.WriteString(w, "application/vnd.oasis.opendocument.spreadsheet")
+
+	w, _ = z.Create("content.xml")
+	io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:spreadsheet>`)
+	for _, sheet := range sheets {
+		io.WriteString(w, `<table:table table:name="`+sheet.name+`"><table:table-column table:number-columns-repeated="3"/>`+sheet.rows+`</table:table>`)
+	}
+	io.WriteString(w, `</office:spreadsheet></office:body></office:document-content>`)
+
+	z.Close()
+	return b.Bytes()
+}
+
+func readSheets(r Reader) ([][]string, []Column, error) {
+	var columns []Column
+	if sr, ok := r.(SchemaReader); ok {
+		var err error
+		columns, err = sr.Schema()
+		if err != nil && err != ErrNoSchema {
+			return nil, nil, err
+		}
+	}
+
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, columns, nil
+		}
+		if err != nil {
+			return records, columns, err
+		}
+		records = append(records, record)
+	}
+}
+
+func TestXLSReader(t *testing.T) {
+	data := buildXLS([]biffSheet{
+		{
+			name: "Title",
+			cells: []biffCell{
+				sstCell(0, 0, 4),
+			},
+		},
+		{
+			name: "January",
+			cells: []biffCell{
+				sstCell(0, 0, 0),
+				sstCell(0, 1, 1),
+				sstCell(0, 2, 2),
+				rkCell(1, 0, 1),
+				numberCell(1, 1, 0, 2.5),
+				numberCell(1, 2, 1, 44562),
+				rkCell(3, 0, 2),
+				{biffLabel, le(uint16(3), uint16(1), uint16(0), uint16(3), uint8(0), []byte("abc"))},
+				{biffBoolErr, le(uint16(3), uint16(2), uint16(0), uint8(1), uint8(0))},
+			},
+		},
+		{
+			name: "February",
+			cells: []biffCell{
+				sstCell(0, 0, 0),
+				sstCell(0, 1, 1),
+				sstCell(0, 2, 2),
+				rkCell(1, 0, 3),
+				{biffFormula, le(uint16(1), uint16(1), uint16(0), []byte{0, 0, 0, 0, 0, 0, 0xff, 0xff}, uint16(0), uint32(0))},
+				{biffString, le(uint16(2), uint8(0), []byte("hi"))},
+				{biffFormula, le(uint16(1), uint16(2), uint16(0), []byte{2, 0, 0x07, 0, 0, 0, 0xff, 0xff}, uint16(0), uint32(0))},
+				rkCell(2, 0, 4),
+				{biffFormula, le(uint16(2), uint16(1), uint16(0), []byte{0, 0, 0, 0, 0, 0, 0xff, 0xff}, uint16(0x08), uint32(0))},
+				{biffShrFmla, le(uint16(2), uint16(3), uint8(1), uint8(1), uint16(0), uint16(0))},
+				{biffString, le(uint16(5), uint8(0), []byte("there"))},
+			},
+		},
+	}, []string{"id", "amount", "date", "unused", "Monthly report"})
+
+	testCases := []struct {
+		Name    string
+		Opts    []ExcelOption
+		Header  []string
+		Records [][]string
+	}{
+		{
+			Name:    "FirstSheet",
+			Records: [][]string{{"Monthly report"}},
+		},
+		{
+			Name:   "SheetName",
+			Opts:   []ExcelOption{SheetName("January"), HeaderRow(1)},
+			Header: []string{"id", "amount", "date"},
+			Records: [][]string{
+				{"1", "2.5", "2022-01-01"},
+				{},
+				{"2", "abc", "TRUE"},
+			},
+		},
+		{
+			Name:   "SheetPattern",
+			Opts:   []ExcelOption{SheetPattern("*uary"), SheetColumn("sheet"), HeaderRow(1), StopAtEmptyRow(true)},
+			Header: []string{"sheet", "id", "amount", "date"},
+			Records: [][]string{
+				{"January", "1", "2.5", "2022-01-01"},
+				{"February", "3", "hi", "#DIV/0!"},
+				{"February", "4", "there"},
+			},
+		},
+		{
+			Name: "CellRange",
+			Opts: []ExcelOption{SheetIndex(2), CellRange("B2:C4")},
+			Records: [][]string{
+				{"2.5", "2022-01-01"},
+				{},
+				{"abc", "TRUE"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			records, columns, err := readSheets(NewXLSReader(bytes.NewReader(data), testCase.Opts...))
+			if err != nil {
+				subT.Log(err)
+				subT.Fail()
+				return
+			}
+
+			if len(columns) != len(testCase.Header) {
+				subT.Logf("expected header: %q\ngot: %v", testCase.Header, columns)
+				subT.Fail()
+				return
+			}
+			for i, col := range columns {
+				if col.Name != testCase.Header[i] {
+					subT.Logf("expected header: %q\ngot: %v", testCase.Header, columns)
+					subT.Fail()
+					return
+				}
+			}
+
+			if fmt.Sprintf("%q", testCase.Records) != fmt.Sprintf("%q", records) {
+				subT.Logf("expected: %q\ngot: %q", testCase.Records, records)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestODSReader(t *testing.T) {
+	data := buildODS([]odsSheet{
+		{
+			name: "January",
+			rows: `<table:table-header-rows><table:table-row>` +
+				`<table:table-cell office:value-type="string"><text:p>id</text:p></table:table-cell>` +
+				`<table:table-cell office:value-type="string"><text:p>note</text:p></table:table-cell>` +
+				`<table:table-cell office:value-type="string"><text:p>date</text:p></table:table-cell>` +
+				`</table:table-row></table:table-header-rows>` +
+				`<table:table-row>` +
+				`<table:table-cell office:value-type="float" office:value="1"><text:p>1.00</text:p></table:table-cell>` +
+				`<table:table-cell office:value-type="string"><text:p>a<text:s text:c="2"/>b</text:p><text:p>c</text:p></table:table-cell>` +
+				`<table:table-cell office:value-type="date" office:date-value="2022-01-01"><text:p>01/01/22</text:p></table:table-cell>` +
+				`</table:table-row>` +
+				`<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>` +
+				`<table:table-row>` +
+				`<table:table-cell table:number-columns-repeated="2" office:value-type="boolean" office:boolean-value="true"><text:p>TRUE</text:p></table:table-cell>` +
+				`<table:table-cell office:value-type="time" office:time-value="PT10H30M00S"><text:p>10:30</text:p></table:table-cell>` +
+				`<table:table-cell table:number-columns-repeated="1021"/>` +
+				`</table:table-row>` +
+				`<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>`,
+		},
+		{
+			name: "February",
+			rows: `<table:table-row><table:table-cell office:value-type="string"><text:p>id</text:p></table:table-cell></table:table-row>` +
+				`<table:table-row>` +
+				`<table:table-cell/><table:table-cell office:value-type="date" office:date-value="2022-02-01T08:15:00"><text:p>02/01/22 08:15</text:p></table:table-cell>` +
+				`</table:table-row>`,
+		},
+	})
+
+	testCases := []struct {
+		Name    string
+		Opts    []ExcelOption
+		Header  []string
+		Records [][]string
+	}{
+		{
+			Name:   "FirstSheet",
+			Opts:   []ExcelOption{HeaderRow(1)},
+			Header: []string{"id", "note", "date"},
+			Records: [][]string{
+				{"1", "a  b\nc", "2022-01-01"},
+				{},
+				{},
+				{"TRUE", "TRUE", "10:30:00"},
+			},
+		},
+		{
+			Name:    "SheetName",
+			Opts:    []ExcelOption{SheetName("February")},
+			Records: [][]string{{"id"}, {"", "2022-02-01 08:15:00"}},
+		},
+		{
+			Name: "AllSheets",
+			Opts: []ExcelOption{SheetPattern("*"), SheetColumn("sheet"), SkipRows(1), StopAtEmptyRow(true)},
+			Records: [][]string{
+				{"January", "1", "a  b\nc", "2022-01-01"},
+				{"February", "", "2022-02-01 08:15:00"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			records, columns, err := readSheets(NewODSReader(bytes.NewReader(data), testCase.Opts...))
+			if err != nil {
+				subT.Log(err)
+				subT.Fail()
+				return
+			}
+
+			if len(columns) != len(testCase.Header) {
+				subT.Logf("expected header: %q\ngot: %v", testCase.Header, columns)
+				subT.Fail()
+				return
+			}
+			for i, col := range columns {
+				if col.Name != testCase.Header[i] {
+					subT.Logf("expected header: %q\ngot: %v", testCase.Header, columns)
+					subT.Fail()
+					return
+				}
+			}
+
+			if fmt.Sprintf("%q", testCase.Records) != fmt.Sprintf("%q", records) {
+				subT.Logf("expected: %q\ngot: %q", testCase.Records, records)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestODSReaderRepeatLimits(t *testing.T) {
+	data := buildODS([]odsSheet{
+		{
+			name: "Sheet1",
+			rows: `<table:table-row table:number-rows-repeated="2000000000">` +
+				`<table:table-cell office:value-type="string"><text:p>a<text:s text:c="-1"/>b<text:s text:c="2000000000"/></text:p></table:table-cell>` +
+				`<table:table-cell table:number-columns-repeated="2000000000" office:value-type="string"><text:p>x</text:p></table:table-cell>` +
+				`</table:table-row>`,
+		},
+	})
+
+	r := NewODSReader(bytes.NewReader(data))
+	for i := 0; i < 2; i++ {
+		record, err := r.Read()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(record) != odsMaxColumns {
+			t.Logf("expected %d fields\ngot: %d", odsMaxColumns, len(record))
+			t.Fail()
+			return
+		}
+		if !strings.HasPrefix(record[0], "a b ") || len(record[0]) != odsMaxCellText {
+			t.Logf("expected a cell of %d characters\ngot: %q...", odsMaxCellText, record[0][:8])
+			t.Fail()
+		}
+	}
+}
+
+// corruptSST builds an .xls workbook whose SST claims
+// to hold far more strings than it does.
+func corruptSST() []byte {
+	data := buildXLS([]biffSheet{{name: "Sheet1"}}, []string{"ab", "cd"})
+	i := bytes.Index(data, le(uint32(2), uint32(2), uint16(2)))
+	binary.LittleEndian.PutUint32(data[i+4:], math.MaxUint32)
+	return data
+}
+
+func TestSheetReaderErrors(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Reader Reader
+	}{
+		{
+			Name:   "NotXLS",
+			Reader: NewXLSReader(bytes.NewReader([]byte("id,name\n"))),
+		},
+		{
+			Name:   "NotODS",
+			Reader: NewODSReader(bytes.NewReader([]byte("id,name\n"))),
+		},
+		{
+			Name:   "CorruptSST",
+			Reader: NewXLSReader(bytes.NewReader(corruptSST())),
+		},
+		{
+			Name:   "UnknownSheet",
+			Reader: NewODSReader(bytes.NewReader(buildODS([]odsSheet{{name: "Sheet1"}})), SheetName("Sheet2")),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			_, err := testCase.Reader.Read()
+			if err == nil || err == io.EOF {
+				subT.Logf("expected an error but got %v", err)
+				subT.Fail()
+			}
+		})
+	}
+}
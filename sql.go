@@ -25,7 +25,13 @@ package tblconv
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"io"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,46 +42,237 @@ type SQLReader struct {
 	tctx   context.Context
 	cancel func()
 
+	cfg   sqlReaderConfig
 	query string
 	args  []interface{}
 
 	rows        *sql.Rows
 	columnNames []string
+	columnKinds []columnKind
+	formats     []valueFormat
 }
 
 // NewSQLReader
 func NewSQLReader(db *sql.DB, query string, args ...interface{}) *SQLReader {
+	return NewSQLReaderWithOptions(db, query, args)
+}
+
+// NewSQLReaderWithOptions is the same as NewSQLReader except the
+// SQLReader is configured with the given options, e.g. NullString.
+func NewSQLReaderWithOptions(db *sql.DB, query string, args []interface{}, opts ...SQLReaderOption) *SQLReader {
+	cfg := sqlReaderConfig{
+		timeFormat: time.RFC3339Nano,
+		dateFormat: dateLayout,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	return &SQLReader{
 		db:    db,
+		cfg:   cfg,
 		query: query,
 		args:  args,
 	}
 }
 
 // Read
-func (r *SQLReader) Read() (record []string, err error) {
+func (r *SQLReader) Read() ([]string, error) {
+	return r.ReadContext(context.Background())
+}
+
+// ReadContext reads the next row of the query result. The query is executed
+// within a sql.Tx which is bound to the context given to the first call
+// of ReadContext, ReadTypedContext or SchemaContext. If that context is
+// done before all rows have been read, the sql.Tx is rolled back.
+//
+// Values are formatted the same regardless of the driver: timestamps as
+// RFC 3339, dates as 2006-01-02, binary values as hex, decimals without
+// exponent or insignificant zeros and booleans as true or false. See
+// TimeFormat, DateFormat, BinaryFormat and FormatColumn to change these.
+//
+func (r *SQLReader) ReadContext(ctx context.Context) ([]string, error) {
+	err := r.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.formats == nil {
+		err = r.bindFormats()
+		if err != nil {
+			r.commitAndCloseRows()
+			return nil, err
+		}
+	}
+
+	values, err := scanTyped(r.rows, r.columnKinds)
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]string, len(values))
+	for i, val := range values {
+		record[i] = r.formats[i].format(val, r.cfg.null)
+	}
+	return record, nil
+}
+
+// bindFormats resolves the format of each column, according
+// to its Kind and the options of the reader.
+func (r *SQLReader) bindFormats() error {
+	err := r.bindKinds()
+	if err != nil {
+		return err
+	}
+
+	columns := columnsNamed(r.columnNames)
+	formats := make([]valueFormat, len(columns))
+	for i, kind := range r.columnKinds {
+		formats[i] = valueFormat{
+			layout: r.cfg.timeFormat,
+			binary: r.cfg.binary,
+			bytes:  kind.known && kind.Kind == BytesKind,
+		}
+		if kind.Kind == DateKind {
+			formats[i].layout = r.cfg.dateFormat
+		}
+	}
+
+	for column, format := range r.cfg.columnFormats {
+		i, err := columnIndex(columns, column)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case formats[i].bytes:
+			formats[i].binary, err = ParseBinaryFormat(format)
+			if err != nil {
+				return err
+			}
+		default:
+			formats[i].layout = format
+		}
+	}
+	r.formats = formats
+	return nil
+}
+
+func (r *SQLReader) bindKinds() error {
+	if r.columnKinds != nil {
+		return nil
+	}
+
+	colTypes, err := r.rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	r.columnKinds = make([]columnKind, len(colTypes))
+	for i, colType := range colTypes {
+		r.columnKinds[i] = kindOfColumn(colType)
+	}
+	return nil
+}
+
+// ReadTyped
+func (r *SQLReader) ReadTyped() ([]Value, error) {
+	return r.ReadTypedContext(context.Background())
+}
+
+// ReadTypedContext reads the next row as typed values. The Kind of each value
+// is determined by the database type of its column, falling back to the
+// type returned by the driver when the column type is not recognized.
+func (r *SQLReader) ReadTypedContext(ctx context.Context) ([]Value, error) {
+	err := r.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.bindKinds()
+	if err != nil {
+		r.commitAndCloseRows()
+		return nil, err
+	}
+
+	return scanTyped(r.rows, r.columnKinds)
+}
+
+// Schema
+func (r *SQLReader) Schema() ([]Column, error) {
+	return r.SchemaContext(context.Background())
+}
+
+// SchemaContext returns the columns of the query result as reported by the
+// database driver. The query is executed if it hasn't been already.
+func (r *SQLReader) SchemaContext(ctx context.Context) ([]Column, error) {
 	if r.tx == nil {
-		r.tctx, r.cancel = context.WithCancel(context.Background())
-		r.tx, err = r.db.BeginTx(r.tctx, nil)
+		err := r.start(ctx)
 		if err != nil {
-			return
+			return nil, err
+		}
+	}
+
+	colTypes, err := r.rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Column, len(colTypes))
+	for i, colType := range colTypes {
+		nullable, ok := colType.Nullable()
+		columns[i] = Column{
+			Name:         colType.Name(),
+			Kind:         kindOfColumn(colType).Kind,
+			DatabaseType: colType.DatabaseTypeName(),
+			Nullable:     nullable || !ok,
 		}
+	}
+	return columns, nil
+}
+
+func (r *SQLReader) start(ctx context.Context) (err error) {
+	r.tctx, r.cancel = context.WithCancel(ctx)
+	r.tx, err = r.db.BeginTx(r.tctx, nil)
+	if err != nil {
+		r.cancel()
+		r.tx = nil
+		return
+	}
+
+	r.rows, err = query(r.tctx, r.tx, r.query, r.args...)
+	if err != nil {
+		r.rollback()
+		return
+	}
+	return nil
+}
 
-		r.rows, err = query(r.tctx, r.tx, r.query, r.args...)
+func (r *SQLReader) next(ctx context.Context) (err error) {
+	if r.tx == nil {
+		err = r.start(ctx)
 		if err != nil {
-			r.rollback()
 			return
 		}
 	}
 
+	err = ctx.Err()
+	if err != nil {
+		r.rows.Close()
+		r.rollback()
+		return
+	}
+
 	if !r.rows.Next() {
 		err = r.rows.Err()
 		if err != nil {
+			r.rows.Close()
+			r.rollback()
 			return
 		}
 
 		r.commitAndCloseRows()
-		return nil, io.EOF
+		return io.EOF
 	}
 
 	if r.columnNames == nil {
@@ -85,8 +282,19 @@ func (r *SQLReader) Read() (record []string, err error) {
 			return
 		}
 	}
+	return nil
+}
 
-	return scan(r.rows, r.columnNames)
+// Abort closes the query result and rolls back the underlying
+// sql.Tx, if any.
+func (r *SQLReader) Abort() error {
+	if r.tx == nil {
+		return nil
+	}
+
+	r.rows.Close()
+	r.rollback()
+	return nil
 }
 
 func (r *SQLReader) rollback() {
@@ -94,6 +302,7 @@ func (r *SQLReader) rollback() {
 	r.cancel()
 	r.tx = nil
 	r.tctx = nil
+	r.rows = nil
 }
 
 func (r *SQLReader) commitAndCloseRows() {
@@ -109,11 +318,11 @@ func query(tctx context.Context, tx *sql.Tx, query string, args ...interface{})
 	return tx.QueryContext(tctx, query, args...)
 }
 
-func scan(rows *sql.Rows, columnNames []string) ([]string, error) {
-	record := make([]string, len(columnNames))
-	refs := make([]interface{}, 0, len(record))
-	for i := range record {
-		refs = append(refs, &record[i])
+func scanTyped(rows *sql.Rows, kinds []columnKind) ([]Value, error) {
+	dest := make([]interface{}, len(kinds))
+	refs := make([]interface{}, 0, len(dest))
+	for i := range dest {
+		refs = append(refs, &dest[i])
 	}
 
 	err := rows.Scan(refs...)
@@ -121,23 +330,419 @@ func scan(rows *sql.Rows, columnNames []string) ([]string, error) {
 		return nil, err
 	}
 
+	record := make([]Value, len(dest))
+	for i, v := range dest {
+		if !kinds[i].known {
+			record[i] = valueOf(v)
+			continue
+		}
+		record[i] = convertValue(v, kinds[i].Kind)
+	}
 	return record, nil
 }
 
+type columnKind struct {
+	Kind
+	known bool
+}
+
+// kindOfColumn maps the database type of a column onto a Kind.
+func kindOfColumn(colType *sql.ColumnType) columnKind {
+	typeName := strings.ToUpper(colType.DatabaseTypeName())
+	typeName = strings.TrimPrefix(typeName, "UNSIGNED ")
+	if i := strings.IndexAny(typeName, "( "); i > 0 {
+		typeName = typeName[:i]
+	}
+
+	switch typeName {
+	case "INT", "INT2", "INT4", "INT8", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "YEAR":
+		return columnKind{Kind: IntKind, known: true}
+	case "FLOAT", "FLOAT4", "FLOAT8", "REAL", "DOUBLE":
+		return columnKind{Kind: FloatKind, known: true}
+	case "NUMERIC", "DECIMAL", "NUMBER", "MONEY":
+		return columnKind{Kind: DecimalKind, known: true}
+	case "FIXED":
+		// Snowflake reports every NUMBER column as FIXED
+		if _, scale, ok := colType.DecimalSize(); ok && scale == 0 {
+			return columnKind{Kind: IntKind, known: true}
+		}
+		return columnKind{Kind: DecimalKind, known: true}
+	case "BOOL", "BOOLEAN":
+		return columnKind{Kind: BoolKind, known: true}
+	case "DATE":
+		return columnKind{Kind: DateKind, known: true}
+	case "DATETIME", "TIMESTAMP", "TIMESTAMPTZ", "TIMESTAMP_LTZ", "TIMESTAMP_NTZ", "TIMESTAMP_TZ":
+		return columnKind{Kind: TimeKind, known: true}
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY":
+		return columnKind{Kind: BytesKind, known: true}
+	case "CHAR", "VARCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "BPCHAR", "NCHAR", "NVARCHAR", "STRING", "UUID", "JSON", "JSONB":
+		return columnKind{Kind: StringKind, known: true}
+	}
+
+	return kindOfScanType(colType.ScanType())
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+func kindOfScanType(t reflect.Type) columnKind {
+	if t == nil {
+		return columnKind{}
+	}
+
+	switch t {
+	case timeType, reflect.TypeOf(sql.NullTime{}):
+		return columnKind{Kind: TimeKind, known: true}
+	case bytesType:
+		return columnKind{Kind: BytesKind, known: true}
+	case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}), reflect.TypeOf(sql.NullByte{}):
+		return columnKind{Kind: IntKind, known: true}
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return columnKind{Kind: FloatKind, known: true}
+	case reflect.TypeOf(sql.NullBool{}):
+		return columnKind{Kind: BoolKind, known: true}
+	case reflect.TypeOf(sql.NullString{}):
+		return columnKind{Kind: StringKind, known: true}
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return columnKind{Kind: IntKind, known: true}
+	case reflect.Float32, reflect.Float64:
+		return columnKind{Kind: FloatKind, known: true}
+	case reflect.Bool:
+		return columnKind{Kind: BoolKind, known: true}
+	case reflect.String:
+		return columnKind{Kind: StringKind, known: true}
+	default:
+		return columnKind{}
+	}
+}
+
+type sqlConfig struct {
+	savepoints bool
+	batchSize  int
+	prepared   bool
+
+	table        string
+	dialect      Dialect
+	tableColumns []string
+	columnMap    map[string]string
+	exactIdents  bool
+}
+
+// SQLOption
+type SQLOption func(*sqlConfig)
+
+// Savepoints configures a SQLWriter to execute each record within its own
+// savepoint, so a failed record is rolled back on its own instead of
+// leaving the whole sql.Tx unusable, as is the case with Postgres.
+// This allows failed records to be skipped, see SkipErrors, at
+// the cost of additional round trips per record.
+//
+func Savepoints(enabled bool) SQLOption {
+	return func(cfg *sqlConfig) {
+		cfg.savepoints = enabled
+	}
+}
+
+// BatchSize configures a SQLWriter to insert n records at once, by
+// rewriting its query, which must be an INSERT with a single VALUES row,
+// into a multi-row INSERT, e.g. "INSERT INTO t VALUES ($1, $2)" into
+// "INSERT INTO t VALUES ($1, $2), ($3, $4)". Positional (?) and numbered
+// ($1 or :1) placeholders are supported. Every record must have as many
+// fields as the row has parameters.
+//
+// Records are held until the batch is full or the SQLWriter is flushed.
+// A failed batch is reported by the Write which filled it, or Flush.
+// Since the error can't be attributed to any one record of the batch,
+// it fails the copy even if errors are skipped, see SkipErrors.
+//
+func BatchSize(n int) SQLOption {
+	return func(cfg *sqlConfig) {
+		cfg.batchSize = n
+	}
+}
+
+// Prepared configures a SQLWriter to prepare its query once per sql.Tx
+// and reuse the prepared statement for every record, or every full
+// batch with BatchSize.
+func Prepared(prepared bool) SQLOption {
+	return func(cfg *sqlConfig) {
+		cfg.prepared = prepared
+	}
+}
+
+// InsertInto configures a SQLWriter to insert records into the given table,
+// instead of executing its query, by generating an INSERT in the given
+// dialect. The table may be qualified by its schema, e.g. "public.users".
+//
+// Records are inserted into the given columns of the table, in order.
+// Otherwise, they are inserted into the columns named by the schema
+// given to WriteSchema or else by the first record written, which is
+// taken as a header row and not inserted itself.
+//
+// Identifiers are quoted with Dialect.QuoteIdentifier, so names such as
+// UserID are folded by the database to the case of an unquoted column,
+// while names with spaces or which are reserved words, e.g. order, are
+// quoted. Use ExactIdentifiers to quote every identifier as is instead.
+//
+func InsertInto(name string, dialect Dialect, columns ...string) SQLOption {
+	return func(cfg *sqlConfig) {
+		cfg.table = name
+		cfg.dialect = dialect
+		cfg.tableColumns = columns
+	}
+}
+
+// MapColumn maps a column of the source schema or header row onto the
+// column of the table it is inserted into by a SQLWriter configured
+// with InsertInto, when their names differ. Mapping a column onto ""
+// leaves it out of the INSERT.
+func MapColumn(src, dst string) SQLOption {
+	return func(cfg *sqlConfig) {
+		if cfg.columnMap == nil {
+			cfg.columnMap = make(map[string]string)
+		}
+		cfg.columnMap[src] = dst
+	}
+}
+
+// ExactIdentifiers configures a SQLWriter configured with InsertInto to
+// quote every identifier of its INSERT as is, so each column of the
+// header row or schema must match the case of its column exactly.
+func ExactIdentifiers(exact bool) SQLOption {
+	return func(cfg *sqlConfig) {
+		cfg.exactIdents = exact
+	}
+}
+
+type sqlReaderConfig struct {
+	null          string
+	timeFormat    string
+	dateFormat    string
+	binary        BinaryFormat
+	columnFormats map[string]string
+}
+
+// SQLReaderOption
+type SQLReaderOption func(*sqlReaderConfig)
+
+// NullString sets the field a SQLReader reads for NULL values,
+// e.g. `\N` or "NULL". By default, NULL is read as an empty string.
+// Values read with ReadTyped are NULL values regardless.
+func NullString(token string) SQLReaderOption {
+	return func(cfg *sqlReaderConfig) {
+		cfg.null = token
+	}
+}
+
+// TimeFormat sets the layout, as accepted by time.Format, of the
+// timestamps a SQLReader reads. By default, timestamps are formatted
+// as RFC 3339.
+func TimeFormat(layout string) SQLReaderOption {
+	return func(cfg *sqlReaderConfig) {
+		cfg.timeFormat = layout
+	}
+}
+
+// DateFormat sets the layout, as accepted by time.Format, of the dates
+// a SQLReader reads. By default, dates are formatted as 2006-01-02.
+func DateFormat(layout string) SQLReaderOption {
+	return func(cfg *sqlReaderConfig) {
+		cfg.dateFormat = layout
+	}
+}
+
+// BinaryFormat selects how a SQLReader formats binary values as text.
+type BinaryFormat uint8
+
+const (
+	// HexBinary formats binary values as lowercase hex.
+	HexBinary BinaryFormat = iota
+
+	// Base64Binary formats binary values as standard base64.
+	Base64Binary
+
+	// RawBinary reads binary values as is.
+	RawBinary
+)
+
+var binaryFormatNames = []string{"hex", "base64", "raw"}
+
+// ParseBinaryFormat parses the name of a BinaryFormat,
+// i.e. hex, base64 or raw.
+func ParseBinaryFormat(s string) (BinaryFormat, error) {
+	for i, name := range binaryFormatNames {
+		if strings.EqualFold(s, name) {
+			return BinaryFormat(i), nil
+		}
+	}
+	return 0, fmt.Errorf("tblconv: unknown binary format: %s", s)
+}
+
+// String
+func (f BinaryFormat) String() string {
+	if int(f) < len(binaryFormatNames) {
+		return binaryFormatNames[f]
+	}
+	return "binaryformat(" + strconv.Itoa(int(f)) + ")"
+}
+
+// Binary sets how a SQLReader formats the values of binary columns.
+// By default, they are formatted as hex.
+func Binary(f BinaryFormat) SQLReaderOption {
+	return func(cfg *sqlReaderConfig) {
+		cfg.binary = f
+	}
+}
+
+// FormatColumn sets the format of a single column read by a SQLReader,
+// given by name or by position counting from 1. The format is a time
+// layout for date and timestamp columns, or the name of a BinaryFormat
+// for binary columns. It is ignored for columns of other kinds.
+func FormatColumn(column, format string) SQLReaderOption {
+	return func(cfg *sqlReaderConfig) {
+		if cfg.columnFormats == nil {
+			cfg.columnFormats = make(map[string]string)
+		}
+		cfg.columnFormats[column] = format
+	}
+}
+
+// valueFormat formats the values of a column read by a SQLReader.
+type valueFormat struct {
+	layout string
+	binary BinaryFormat
+
+	// bytes is whether the column is known to be binary, as drivers
+	// also return text as []byte.
+	bytes bool
+}
+
+func (f valueFormat) format(val Value, null string) string {
+	if val.Null {
+		return null
+	}
+
+	switch x := val.V.(type) {
+	case time.Time:
+		return x.Format(f.layout)
+	case []byte:
+		if !f.bytes {
+			return string(x)
+		}
+		switch f.binary {
+		case Base64Binary:
+			return base64.StdEncoding.EncodeToString(x)
+		case RawBinary:
+			return string(x)
+		default:
+			return hex.EncodeToString(x)
+		}
+	}
+
+	if val.Kind == DecimalKind {
+		return canonicalDecimal(val.String())
+	}
+	return val.String()
+}
+
 // SQLWriter
 type SQLWriter struct {
 	db *sql.DB
 	tx *sql.Tx
 
+	// ctx is the context the sql.Tx is bound to
+	ctx context.Context
+
+	cfg   sqlConfig
 	query string
+	stmt  *sql.Stmt
+
+	// batch is the parsed query, if writing in batches.
+	batch     *batchQuery
+	batchStmt string
+	pending   []interface{}
+	rows      int
+
+	// width is the number of fields of a record written to a table,
+	// of which only fields are inserted, unless nil.
+	width  int
+	fields []int
 }
 
 // NewSQLWriter
-func NewSQLWriter(db *sql.DB, query string) *SQLWriter {
-	return &SQLWriter{
+func NewSQLWriter(db *sql.DB, query string, opts ...SQLOption) *SQLWriter {
+	var cfg sqlConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	w := &SQLWriter{
 		db:    db,
+		cfg:   cfg,
 		query: query,
 	}
+	if cfg.table != "" {
+		w.query = ""
+		if len(cfg.tableColumns) > 0 {
+			w.query = cfg.dialect.insertStatement(cfg.table, cfg.tableColumns, cfg.exactIdents)
+			w.width = len(cfg.tableColumns)
+		}
+	}
+	return w
+}
+
+// WriteSchema sets the columns records are inserted into,
+// if the SQLWriter was configured with InsertInto without columns.
+func (w *SQLWriter) WriteSchema(columns []Column) error {
+	if w.cfg.table == "" || w.query != "" {
+		return nil
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return w.buildInsert(names)
+}
+
+// buildInsert generates the query inserting records with
+// the given columns into the table, mapping them by name.
+func (w *SQLWriter) buildInsert(names []string) error {
+	for src := range w.cfg.columnMap {
+		if !containsString(names, src) {
+			return fmt.Errorf("tblconv: mapped column is not a source column: %s", src)
+		}
+	}
+
+	var columns []string
+	var fields []int
+	for i, name := range names {
+		col, mapped := w.cfg.columnMap[name]
+		if !mapped {
+			col = name
+		}
+		if col == "" {
+			continue
+		}
+		columns = append(columns, col)
+		fields = append(fields, i)
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("tblconv: no columns to insert into table: %s", w.cfg.table)
+	}
+
+	w.query = w.cfg.dialect.insertStatement(w.cfg.table, columns, w.cfg.exactIdents)
+	w.width = len(names)
+	if len(fields) < len(names) {
+		w.fields = fields
+	}
+	return nil
 }
 
 // Write uses the given record to fill an placeholder parameters in the query
@@ -148,19 +753,161 @@ func NewSQLWriter(db *sql.DB, query string) *SQLWriter {
 // After flushing, a new sql.Tx will be created so with periodic flushing
 // there is no gaurantee that all writes will occur in the same transaction.
 //
-func (w *SQLWriter) Write(record []string) (err error) {
+func (w *SQLWriter) Write(record []string) error {
+	return w.WriteContext(context.Background(), record)
+}
+
+// WriteContext is the same as Write except the query is executed with
+// the given context. The sql.Tx is bound to the context given to the
+// first write after creation or a Flush. If the context is done before
+// the sql.Tx is flushed, the sql.Tx is rolled back.
+//
+func (w *SQLWriter) WriteContext(ctx context.Context, record []string) error {
+	if w.cfg.table != "" && w.query == "" {
+		return w.buildInsert(record)
+	}
+	return w.exec(ctx, interfaceSlicize(record))
+}
+
+// WriteTyped
+func (w *SQLWriter) WriteTyped(record []Value) error {
+	return w.WriteTypedContext(context.Background(), record)
+}
+
+// WriteTypedContext is the same as WriteContext except the query parameters
+// are filled in with the native value of each field. NULL values are
+// passed as nil.
+func (w *SQLWriter) WriteTypedContext(ctx context.Context, record []Value) error {
+	if w.cfg.table != "" && w.query == "" {
+		names := make([]string, len(record))
+		for i, val := range record {
+			names[i] = val.String()
+		}
+		return w.buildInsert(names)
+	}
+
+	args := make([]interface{}, len(record))
+	for i, val := range record {
+		args[i] = val.driverValue()
+	}
+	return w.exec(ctx, args)
+}
+
+func (w *SQLWriter) exec(ctx context.Context, args []interface{}) (err error) {
+	if w.width > 0 && len(args) != w.width {
+		return fmt.Errorf("tblconv: record has %d fields but %d columns were expected", len(args), w.width)
+	}
+	if w.fields != nil {
+		inserted := make([]interface{}, len(w.fields))
+		for i, field := range w.fields {
+			inserted[i] = args[field]
+		}
+		args = inserted
+	}
+
 	if w.tx == nil {
-		w.tx, err = w.db.Begin()
+		w.tx, err = w.db.BeginTx(ctx, nil)
+		if err != nil {
+			return
+		}
+		w.ctx = ctx
+	}
+
+	if w.cfg.batchSize <= 1 {
+		return w.run(ctx, w.query, args, w.cfg.prepared)
+	}
+
+	if w.batch == nil {
+		w.batch, err = parseBatchQuery(w.query)
+		if err != nil {
+			return
+		}
+		w.batchStmt = w.batch.build(w.cfg.batchSize)
+	}
+	if len(args) != w.batch.params {
+		return fmt.Errorf("tblconv: record has %d fields but the query has %d parameters", len(args), w.batch.params)
+	}
+
+	w.pending = append(w.pending, args...)
+	w.rows++
+	if w.rows < w.cfg.batchSize {
+		return nil
+	}
+	return w.execBatch(ctx)
+}
+
+// batchError reports the failure of a batch, which can't be
+// skipped like the failure of a single record.
+type batchError struct {
+	rows int
+	err  error
+}
+
+// Error
+func (e *batchError) Error() string {
+	return fmt.Sprintf("tblconv: batch of %d records failed: %s", e.rows, e.err)
+}
+
+// Unwrap
+func (e *batchError) Unwrap() error {
+	return e.err
+}
+
+// execBatch inserts the pending records of a batch.
+func (w *SQLWriter) execBatch(ctx context.Context) error {
+	query, prepared := w.batchStmt, w.cfg.prepared
+	if w.rows < w.cfg.batchSize {
+		query, prepared = w.batch.build(w.rows), false
+	}
+
+	args, rows := w.pending, w.rows
+	w.pending = nil
+	w.rows = 0
+
+	err := w.run(ctx, query, args, prepared)
+	if err != nil {
+		return &batchError{rows: rows, err: err}
+	}
+	return nil
+}
+
+// run executes query within the sql.Tx, using a prepared statement
+// of it if prepared is set.
+func (w *SQLWriter) run(ctx context.Context, query string, args []interface{}, prepared bool) (err error) {
+	if prepared && w.stmt == nil {
+		w.stmt, err = w.tx.PrepareContext(ctx, query)
 		if err != nil {
 			return
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if w.cfg.savepoints {
+		_, err = w.tx.ExecContext(ctx, "SAVEPOINT tblconv_record")
+		if err != nil {
+			return
+		}
+	}
 
-	args := interfaceSlicize(record)
-	_, err = w.tx.ExecContext(ctx, w.query, args...)
+	if prepared {
+		_, err = w.stmt.ExecContext(ctx, args...)
+	} else {
+		_, err = w.tx.ExecContext(ctx, query, args...)
+	}
+	if err != nil && ctx.Err() != nil {
+		w.Abort()
+		return
+	}
+
+	if w.cfg.savepoints {
+		if err != nil {
+			_, rerr := w.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT tblconv_record")
+			if rerr != nil {
+				return rerr
+			}
+			return
+		}
+		_, err = w.tx.ExecContext(ctx, "RELEASE SAVEPOINT tblconv_record")
+	}
 	return
 }
 
@@ -172,14 +919,50 @@ func interfaceSlicize(ss []string) []interface{} {
 	return is
 }
 
-// Flush commits the underlying sql.Tx. See SQLWriter.Write() for more
-// details about the relationship between Write and Flush for SQLWriter.
+// Abort rolls back the underlying sql.Tx, discarding every record
+// written since the last Flush.
+func (w *SQLWriter) Abort() error {
+	w.pending = nil
+	w.rows = 0
+	if w.tx == nil {
+		return nil
+	}
+	w.closeStmt()
+	tx := w.tx
+	w.tx = nil
+	return tx.Rollback()
+}
+
+// Flush commits the underlying sql.Tx, after inserting the records
+// pending in a batch, if any, with the context the sql.Tx is bound to.
+// If they fail to be inserted, the sql.Tx is rolled back instead. See
+// SQLWriter.Write() for more details about the relationship between
+// Write and Flush for SQLWriter.
 //
 func (w *SQLWriter) Flush() error {
 	if w.tx == nil {
 		return sql.ErrTxDone
 	}
+
+	if w.rows > 0 {
+		err := w.execBatch(w.ctx)
+		if err != nil {
+			if w.tx != nil {
+				w.Abort()
+			}
+			return err
+		}
+	}
+
+	w.closeStmt()
 	tx := w.tx
 	w.tx = nil
 	return tx.Commit()
 }
+
+func (w *SQLWriter) closeStmt() {
+	if w.stmt != nil {
+		w.stmt.Close()
+		w.stmt = nil
+	}
+}
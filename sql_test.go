@@ -23,10 +23,17 @@ THE SOFTWARE.
 package tblconv
 
 import (
+	"bytes"
+	"context"
 	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/xuri/excelize/v2"
 )
 
 func TestSQLReader(t *testing.T) {
@@ -86,6 +93,156 @@ func TestSQLReader(t *testing.T) {
 	}
 }
 
+func TestSQLReaderNulls(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Args     []interface{}
+		Opts     []SQLReaderOption
+		Expected [][]string
+	}{
+		{
+			Name:     "Default",
+			Expected: [][]string{{"0", "tony", ""}, {"1", "", "2"}},
+		},
+		{
+			Name:     "NullString",
+			Opts:     []SQLReaderOption{NullString(`\N`)},
+			Expected: [][]string{{"0", "tony", `\N`}, {"1", `\N`, "2"}},
+		},
+		{
+			Name:     "NullStringWithArgs",
+			Args:     []interface{}{7},
+			Opts:     []SQLReaderOption{NullString("NULL")},
+			Expected: [][]string{{"0", "tony", "NULL"}, {"1", "NULL", "2"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				subT.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			rows := sqlmock.NewRows([]string{"id", "first", "age"}).
+				AddRow(0, "tony", nil).
+				AddRow(1, nil, 2)
+
+			var args []driver.Value
+			for _, arg := range testCase.Args {
+				args = append(args, arg)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("test nulls").WithArgs(args...).WillReturnRows(rows).RowsWillBeClosed()
+			mock.ExpectCommit()
+
+			w := NewRecordsWriter()
+			err = Copy(w, NewSQLReaderWithOptions(db, "test nulls", testCase.Args, testCase.Opts...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				subT.Logf("unmet expectation error: %s", err)
+				subT.Fail()
+				return
+			}
+
+			if fmt.Sprintf("%q", testCase.Expected) != fmt.Sprintf("%q", w.Records()) {
+				subT.Logf("expected: %q\ngot: %q", testCase.Expected, w.Records())
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestSQLReaderFormats(t *testing.T) {
+	ts := time.Date(2022, 5, 29, 13, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		Name     string
+		Opts     []SQLReaderOption
+		Expected []string
+	}{
+		{
+			Name:     "Default",
+			Expected: []string{"2022-05-29T13:04:05Z", "2022-05-29", "cafe", "12.5", "1500", "true", "text"},
+		},
+		{
+			Name:     "Options",
+			Opts:     []SQLReaderOption{TimeFormat("2006-01-02 15:04"), DateFormat("02/01/2006"), Binary(Base64Binary)},
+			Expected: []string{"2022-05-29 13:04", "29/05/2022", "yv4=", "12.5", "1500", "true", "text"},
+		},
+		{
+			Name:     "FormatColumn",
+			Opts:     []SQLReaderOption{FormatColumn("ts", time.Kitchen), FormatColumn("3", "raw")},
+			Expected: []string{"1:04PM", "2022-05-29", "\xca\xfe", "12.5", "1500", "true", "text"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				subT.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			rows := sqlmock.NewRowsWithColumnDefinition(
+				sqlmock.NewColumn("ts").OfType("TIMESTAMPTZ", time.Time{}),
+				sqlmock.NewColumn("born").OfType("DATE", time.Time{}),
+				sqlmock.NewColumn("hash").OfType("BYTEA", []byte{}),
+				sqlmock.NewColumn("price").OfType("NUMERIC", ""),
+				sqlmock.NewColumn("total").OfType("NUMERIC", ""),
+				sqlmock.NewColumn("active").OfType("BOOL", false),
+				sqlmock.NewColumn("note").OfType("VARCHAR", ""),
+			).AddRow(ts, ts, []byte{0xca, 0xfe}, []byte("012.500"), "1.5E3", true, []byte("text"))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT").WillReturnRows(rows).RowsWillBeClosed()
+			mock.ExpectCommit()
+
+			w := NewRecordsWriter()
+			err = Copy(w, NewSQLReaderWithOptions(db, "SELECT", nil, testCase.Opts...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if fmt.Sprintf("%q", [][]string{testCase.Expected}) != fmt.Sprintf("%q", w.Records()) {
+				subT.Logf("expected: %q\ngot: %q", [][]string{testCase.Expected}, w.Records())
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestCanonicalDecimal(t *testing.T) {
+	testCases := map[string]string{
+		"12.50":    "12.5",
+		"+012.500": "12.5",
+		"-0.000":   "0",
+		"100":      "100",
+		"1.5E3":    "1500",
+		"-25e-3":   "-0.025",
+		".5":       "0.5",
+		"NaN":      "NaN",
+	}
+
+	for s, expected := range testCases {
+		t.Run(s, func(subT *testing.T) {
+			actual := canonicalDecimal(s)
+			if expected != actual {
+				subT.Logf("expected: %s\ngot: %s", expected, actual)
+				subT.Fail()
+			}
+		})
+	}
+}
+
 func TestSQLWriter(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -133,3 +290,554 @@ func convert2DriverValues(record []string) []driver.Value {
 	}
 	return vals
 }
+
+func TestSQLWriterBatches(t *testing.T) {
+	records := [][]string{
+		{"0", "tony"},
+		{"1", "clark"},
+		{"2", "bruce"},
+		{"3", "diana"},
+		{"4", "barry"},
+	}
+
+	testCases := []struct {
+		Name   string
+		Query  string
+		Opts   []SQLOption
+		Expect func(sqlmock.Sqlmock)
+	}{
+		{
+			Name:  "Batches",
+			Query: "INSERT INTO t VALUES ($1, $2)",
+			Opts:  []SQLOption{BatchSize(2)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO t VALUES ($1, $2), ($3, $4)").
+					WithArgs("0", "tony", "1", "clark").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO t VALUES ($1, $2), ($3, $4)").
+					WithArgs("2", "bruce", "3", "diana").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO t VALUES ($1, $2)").
+					WithArgs("4", "barry").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name:  "PreparedBatches",
+			Query: "INSERT INTO t VALUES (?, ?)",
+			Opts:  []SQLOption{BatchSize(2), Prepared(true)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				stmt := mock.ExpectPrepare("INSERT INTO t VALUES (?, ?), (?, ?)")
+				stmt.ExpectExec().
+					WithArgs("0", "tony", "1", "clark").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				stmt.ExpectExec().
+					WithArgs("2", "bruce", "3", "diana").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO t VALUES (?, ?)").
+					WithArgs("4", "barry").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name:  "Prepared",
+			Query: "INSERT INTO t VALUES (?, ?)",
+			Opts:  []SQLOption{Prepared(true)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				stmt := mock.ExpectPrepare("INSERT INTO t VALUES (?, ?)")
+				for i, record := range records {
+					stmt.ExpectExec().
+						WithArgs(convert2DriverValues(record)...).
+						WillReturnResult(sqlmock.NewResult(int64(i), 1))
+				}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				subT.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			testCase.Expect(mock)
+			mock.ExpectCommit()
+
+			err = Copy(NewSQLWriter(db, testCase.Query, testCase.Opts...), NewRecordsReader(records...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				subT.Logf("unmet expectation error: %s", err)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestSQLWriterInsertInto(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Reader func() Reader
+		Opts   []SQLOption
+		Expect func(sqlmock.Sqlmock)
+	}{
+		{
+			Name: "Header",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"id", "name"}, []string{"0", "tony"}, []string{"1", "clark"})
+			},
+			Opts: []SQLOption{InsertInto("public.users", PostgresDialect)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO public.users (id, name) VALUES ($1, $2)`).
+					WithArgs("0", "tony").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO public.users (id, name) VALUES ($1, $2)`).
+					WithArgs("1", "clark").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			Name: "MixedCaseHeader",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"UserID", "Full Name", "2fa", "User"}, []string{"0", "tony", "t", "ironman"})
+			},
+			Opts: []SQLOption{InsertInto("Users", PostgresDialect)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO Users (UserID, "Full Name", "2fa", "user") VALUES ($1, $2, $3, $4)`).
+					WithArgs("0", "tony", "t", "ironman").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name:   "Schema",
+			Reader: func() Reader { return NewCSVReader(strings.NewReader("id,name\n0,tony\n"), CSVHeader(true)) },
+			Opts:   []SQLOption{InsertInto("users", MySQLDialect)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO users (id, name) VALUES (?, ?)").
+					WithArgs("0", "tony").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name:   "Columns",
+			Reader: func() Reader { return NewRecordsReader([]string{"0", "tony", "1"}) },
+			Opts:   []SQLOption{InsertInto("users", SnowflakeDialect, "ID", "first name", "order")},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO users (ID, "first name", "ORDER") VALUES (?, ?, ?)`).
+					WithArgs("0", "tony", "1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name: "ExactIdentifiers",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"UserID", "name"}, []string{"0", "tony"})
+			},
+			Opts: []SQLOption{InsertInto("public.Users", PostgresDialect), ExactIdentifiers(true)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO "public"."Users" ("UserID", "name") VALUES ($1, $2)`).
+					WithArgs("0", "tony").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name:   "Generic",
+			Reader: func() Reader { return NewRecordsReader([]string{"id", "Full Name"}, []string{"0", "tony"}) },
+			Opts:   []SQLOption{InsertInto("users", GenericDialect)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO users (id, "Full Name") VALUES (?, ?)`).
+					WithArgs("0", "tony").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name: "MapColumn",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"Name", "Notes", "ID"}, []string{"tony", "genius", "0"})
+			},
+			Opts: []SQLOption{
+				InsertInto("users", PostgresDialect),
+				MapColumn("Name", "name"),
+				MapColumn("ID", "id"),
+				MapColumn("Notes", ""),
+			},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO users (name, id) VALUES ($1, $2)`).
+					WithArgs("tony", "0").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			Name:   "Batches",
+			Reader: func() Reader { return NewRecordsReader([]string{"id"}, []string{"0"}, []string{"1"}, []string{"2"}) },
+			Opts:   []SQLOption{InsertInto("users", PostgresDialect), BatchSize(2)},
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO users (id) VALUES ($1), ($2)`).
+					WithArgs("0", "1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(`INSERT INTO users (id) VALUES ($1)`).
+					WithArgs("2").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				subT.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			testCase.Expect(mock)
+			mock.ExpectCommit()
+
+			err = Copy(NewSQLWriter(db, "", testCase.Opts...), testCase.Reader())
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				subT.Logf("unmet expectation error: %s", err)
+				subT.Fail()
+			}
+		})
+	}
+}
+
+func TestSQLWriterInsertIntoUnknownColumn(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	w := NewSQLWriter(db, "", InsertInto("users", PostgresDialect), MapColumn("Name", "name"))
+	err = w.WriteSchema([]Column{{Name: "id"}, {Name: "name"}})
+	if err == nil {
+		t.Log("expected an error for mapping a column which is not in the schema")
+		t.Fail()
+	}
+}
+
+func TestSQLToSQLTyped(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT8", int64(0)),
+		sqlmock.NewColumn("name").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("score").OfType("FLOAT8", float64(0)),
+	).
+		AddRow([]byte("0"), "tony", 1.5).
+		AddRow(int64(1), nil, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT").WillReturnRows(rows).RowsWillBeClosed()
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT").WithArgs(int64(0), "tony", 1.5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^INSERT").WithArgs(int64(1), nil, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectCommit()
+
+	r := NewSQLReader(db, "SELECT")
+	w := NewSQLWriter(db, "INSERT ? ? ?")
+
+	err = Copy(w, r)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Logf("unmet expectation error: %s", err)
+		t.Fail()
+		return
+	}
+}
+
+func TestSQLToExcelBinary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT8", int64(0)),
+		sqlmock.NewColumn("data").OfType("BLOB", []byte{}),
+	).
+		AddRow(int64(0), []byte{0x00, 0x1b, 'a'})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT").WillReturnRows(rows).RowsWillBeClosed()
+	mock.ExpectCommit()
+
+	var buf bytes.Buffer
+	err = Copy(NewExcelWriter(&buf), NewSQLReader(db, "SELECT"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cell, err := f.GetCellValue(DefaultSheetName, "B2")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cell != "001b61" {
+		t.Logf("expected: %q\ngot: %q", "001b61", cell)
+		t.Fail()
+	}
+}
+
+func TestSQLReaderSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT4", int64(0)).Nullable(false),
+		sqlmock.NewColumn("name").OfType("VARCHAR", "").Nullable(true),
+	).AddRow(int64(0), "tony")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT").WillReturnRows(rows).RowsWillBeClosed()
+	mock.ExpectCommit()
+
+	r := NewSQLReader(db, "SELECT")
+
+	columns, err := r.Schema()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := []Column{
+		{Name: "id", Kind: IntKind, DatabaseType: "INT4", Nullable: false},
+		{Name: "name", Kind: StringKind, DatabaseType: "VARCHAR", Nullable: true},
+	}
+	if len(expected) != len(columns) {
+		t.Logf("expected: %v\ngot: %v", expected, columns)
+		t.Fail()
+		return
+	}
+	for i := range expected {
+		if expected[i] != columns[i] {
+			t.Logf("expected: %v\ngot: %v", expected[i], columns[i])
+			t.Fail()
+			return
+		}
+	}
+
+	var out bytes.Buffer
+	err = Copy(NewCSVWriter(&out), r)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if out.String() != "id,name\n0,tony\n" {
+		t.Logf("unexpected output: %q", out.String())
+		t.Fail()
+		return
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Logf("unmet expectation error: %s", err)
+		t.Fail()
+		return
+	}
+}
+
+func TestSQLWriterCanceled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	records := [][]string{
+		{"0", "tony", "stark", "32"},
+		{"1", "clark", "kent", "2"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT").
+		WithArgs(convert2DriverValues(records[0])...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := &cancelingReader{Reader: NewRecordsReader(records...), cancel: cancel}
+	w := NewSQLWriter(db, "INSERT ? ? ? ?")
+
+	err = CopyContext(ctx, w, r)
+	if err != context.Canceled {
+		t.Logf("expected: %v\ngot: %v", context.Canceled, err)
+		t.Fail()
+		return
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Logf("unmet expectation error: %s", err)
+		t.Fail()
+		return
+	}
+}
+
+// cancelingReader cancels its context when the second record is read.
+type cancelingReader struct {
+	Reader
+
+	cancel func()
+	reads  int
+}
+
+func (r *cancelingReader) Read() ([]string, error) {
+	if r.reads > 0 {
+		r.cancel()
+	}
+	r.reads += 1
+	return r.Reader.Read()
+}
+
+func TestSQLWriterBatchSkipErrors(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	failed := errors.New("invalid input syntax for type integer")
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO t VALUES ($1), ($2)").
+		WithArgs("0", "one").
+		WillReturnError(failed)
+	mock.ExpectRollback()
+
+	rejects := NewRecordsWriter()
+	err = Copy(
+		NewSQLWriter(db, "INSERT INTO t VALUES ($1)", BatchSize(2)),
+		NewRecordsReader([]string{"0"}, []string{"one"}, []string{"2"}),
+		SkipErrors(-1),
+		RejectTo(rejects),
+	)
+	if !errors.Is(err, failed) {
+		t.Logf("expected: %v\ngot: %v", failed, err)
+		t.Fail()
+		return
+	}
+	if records := rejects.Records(); len(records) > 0 {
+		t.Logf("expected no rejected records\ngot: %q", records)
+		t.Fail()
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Logf("unmet expectation error: %s", err)
+		t.Fail()
+	}
+}
+
+func TestSQLWriterFlushCanceled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewSQLWriter(db, "INSERT INTO t VALUES (?)", BatchSize(2))
+	err = w.WriteContext(ctx, []string{"0"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cancel()
+	err = w.Flush()
+	if !errors.Is(err, context.Canceled) {
+		t.Logf("expected: %v\ngot: %v", context.Canceled, err)
+		t.Fail()
+		return
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Logf("unmet expectation error: %s", err)
+		t.Fail()
+	}
+}
+
+func TestSQLWriterSavepoints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	records := [][]string{
+		{"0", "tony"},
+		{"0", "clark"},
+		{"1", "bruce"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT tblconv_record").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT").WithArgs("0", "tony").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT tblconv_record").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT tblconv_record").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT").WithArgs("0", "clark").WillReturnError(errors.New("duplicate key"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT tblconv_record").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT tblconv_record").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT").WithArgs("1", "bruce").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT tblconv_record").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var stats Stats
+	r := NewRecordsReader(records...)
+	w := NewSQLWriter(db, "INSERT ? ?", Savepoints(true))
+
+	err = Copy(w, r, SkipErrors(-1), WithStats(&stats))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if stats.RecordsWritten != 2 || stats.RecordsSkipped != 1 {
+		t.Logf("unexpected stats: %+v", stats)
+		t.Fail()
+		return
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Logf("unmet expectation error: %s", err)
+		t.Fail()
+		return
+	}
+}
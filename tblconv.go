@@ -24,7 +24,8 @@ THE SOFTWARE.
 package tblconv
 
 import (
-	"io"
+	"context"
+	"errors"
 )
 
 // Reader
@@ -37,6 +38,84 @@ type Writer interface {
 	Write(record []string) error
 }
 
+// TypedReader is implemented by Readers which can provide records as
+// typed values instead of strings.
+type TypedReader interface {
+	ReadTyped() ([]Value, error)
+}
+
+// TypedWriter is implemented by Writers which can make use of the type
+// information carried by a Value.
+type TypedWriter interface {
+	WriteTyped(record []Value) error
+}
+
+// ReaderContext is an optional interface for Readers which
+// can abandon a Read when the given context is done.
+type ReaderContext interface {
+	ReadContext(ctx context.Context) ([]string, error)
+}
+
+// WriterContext is an optional interface for Writers which
+// can abandon a Write when the given context is done.
+type WriterContext interface {
+	WriteContext(ctx context.Context, record []string) error
+}
+
+// TypedReaderContext is the TypedReader equivalent of ReaderContext.
+type TypedReaderContext interface {
+	ReadTypedContext(ctx context.Context) ([]Value, error)
+}
+
+// TypedWriterContext is the TypedWriter equivalent of WriterContext.
+type TypedWriterContext interface {
+	WriteTypedContext(ctx context.Context, record []Value) error
+}
+
+// Aborter is an optional interface for Readers and Writers to implement
+// if they hold resources which must be released when a copy is abandoned,
+// e.g. an open transaction which should be rolled back.
+type Aborter interface {
+	Abort() error
+}
+
+// Column describes a single column of tabulized data.
+type Column struct {
+	Name string
+	Kind Kind
+
+	// DatabaseType is the name of the column type as reported by
+	// the source, e.g. "VARCHAR". It is empty if the source is untyped.
+	DatabaseType string
+
+	// Nullable reports whether the column may contain NULL values.
+	// It is true whenever the source can not tell.
+	Nullable bool
+}
+
+// ErrNoSchema is returned by SchemaReader.Schema when the reader does
+// not know the columns of the data it reads, e.g. a CSV file without
+// a header row.
+var ErrNoSchema = errors.New("tblconv: no schema available")
+
+// SchemaReader is an optional interface for Readers which know the names
+// and types of the columns they read. Column names returned by Schema
+// are never returned by Read.
+type SchemaReader interface {
+	Schema() ([]Column, error)
+}
+
+// SchemaReaderContext is the SchemaReader equivalent of ReaderContext.
+type SchemaReaderContext interface {
+	SchemaContext(ctx context.Context) ([]Column, error)
+}
+
+// SchemaWriter is an optional interface for Writers which can make use of
+// the schema of the records being written, e.g. to write a header row.
+type SchemaWriter interface {
+	WriteSchema(columns []Column) error
+}
+
 // Flusher is an optional interface for Writers to implement
 // if they need to be flushed after writing all the records.
 type Flusher interface {
@@ -46,22 +125,180 @@ type Flusher interface {
 // Copy provides the ability to copy tabulized data
 // from one format to another.
 //
-func Copy(w Writer, r Reader) error {
-	for {
-		record, err := r.Read()
-		if err == io.EOF {
-			if f, ok := w.(Flusher); ok {
-				return f.Flush()
-			}
-			return nil
-		}
-		if err != nil {
-			return err
-		}
+// If r implements SchemaReader and w implements SchemaWriter, the
+// schema of r is given to w before any records are copied.
+//
+// If r implements TypedReader and w implements TypedWriter,
+// records are copied as typed values. Otherwise, they are
+// copied as strings.
+//
+func Copy(w Writer, r Reader, opts ...CopyOption) error {
+	return CopyContext(context.Background(), w, r, opts...)
+}
 
-		err = w.Write(record)
-		if err != nil {
-			return err
+// CopyContext is the same as Copy except the copy is abandoned as soon
+// as ctx is done. The context is passed along to readers and writers which
+// implement the context-aware variants of Reader and Writer, such as
+// ReaderContext. If the copy is abandoned, w is not flushed.
+//
+func CopyContext(ctx context.Context, w Writer, r Reader, opts ...CopyOption) error {
+	cfg := newCopyConfig(opts)
+
+	if tw, ok := w.(TypedWriter); ok {
+		if tr, ok := r.(TypedReader); ok {
+			c := newCopier(cfg, typedReadFunc(tr), typedWriteFunc(tw), typedRecordSize, stringify)
+			return c.run(ctx, w, r)
 		}
 	}
+
+	c := newCopier(cfg, readFunc(r), writeFunc(w), recordSize, recordStrings)
+	return c.run(ctx, w, r)
+}
+
+// CopyTyped is the same as Copy except records are always copied
+// as typed values. Use NewTypedReader and NewTypedWriter to adapt
+// a string based Reader or Writer.
+//
+func CopyTyped(w TypedWriter, r TypedReader, opts ...CopyOption) error {
+	return CopyTypedContext(context.Background(), w, r, opts...)
+}
+
+// CopyTypedContext is the same as CopyContext except records are
+// always copied as typed values.
+func CopyTypedContext(ctx context.Context, w TypedWriter, r TypedReader, opts ...CopyOption) error {
+	c := newCopier(newCopyConfig(opts), typedReadFunc(r), typedWriteFunc(w), typedRecordSize, stringify)
+	return c.run(ctx, w, r)
+}
+
+type typedReader struct {
+	r Reader
+}
+
+// NewTypedReader adapts a Reader into a TypedReader. Every field
+// is read as a StringKind Value.
+func NewTypedReader(r Reader) TypedReader {
+	return typedReader{r: r}
+}
+
+// ReadTyped
+func (r typedReader) ReadTyped() ([]Value, error) {
+	record, err := r.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]Value, len(record))
+	for i, field := range record {
+		vals[i] = StringValue(field)
+	}
+	return vals, nil
+}
+
+type typedWriter struct {
+	w Writer
+}
+
+// NewTypedWriter adapts a Writer into a TypedWriter. Values are
+// formatted with Value.String before being written. If w implements
+// Flusher, so does the returned TypedWriter.
+func NewTypedWriter(w Writer) TypedWriter {
+	return typedWriter{w: w}
+}
+
+// WriteTyped
+func (w typedWriter) WriteTyped(record []Value) error {
+	return w.w.Write(stringify(record))
+}
+
+// Flush
+func (w typedWriter) Flush() error {
+	if f, ok := w.w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+type untypedReader struct {
+	r TypedReader
+}
+
+// NewUntypedReader adapts a TypedReader into a Reader. Values are
+// formatted with Value.String.
+func NewUntypedReader(r TypedReader) Reader {
+	return untypedReader{r: r}
+}
+
+// Read
+func (r untypedReader) Read() ([]string, error) {
+	vals, err := r.r.ReadTyped()
+	if err != nil {
+		return nil, err
+	}
+	return stringify(vals), nil
+}
+
+type untypedWriter struct {
+	w TypedWriter
+}
+
+// NewUntypedWriter adapts a TypedWriter into a Writer. Every field
+// is written as a StringKind Value. If w implements Flusher, so does
+// the returned Writer.
+func NewUntypedWriter(w TypedWriter) Writer {
+	return untypedWriter{w: w}
+}
+
+// Write
+func (w untypedWriter) Write(record []string) error {
+	vals := make([]Value, len(record))
+	for i, field := range record {
+		vals[i] = StringValue(field)
+	}
+	return w.w.WriteTyped(vals)
+}
+
+// Flush
+func (w untypedWriter) Flush() error {
+	if f, ok := w.w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func stringify(vals []Value) []string {
+	record := make([]string, len(vals))
+	for i, val := range vals {
+		record[i] = val.String()
+	}
+	return record
+}
+
+// columnsNamed returns untyped columns with the given names.
+func columnsNamed(names []string) []Column {
+	columns := make([]Column, len(names))
+	for i, name := range names {
+		columns[i] = Column{Name: name, Kind: StringKind, Nullable: true}
+	}
+	return columns
+}
+
+func columnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// readSchema returns the schema of r, or ErrNoSchema if r does
+// not provide one.
+func readSchema(ctx context.Context, r interface{}) ([]Column, error) {
+	switch sr := r.(type) {
+	case SchemaReaderContext:
+		return sr.SchemaContext(ctx)
+	case SchemaReader:
+		return sr.Schema()
+	default:
+		return nil, ErrNoSchema
+	}
 }
@@ -24,10 +24,13 @@ package tblconv
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"io/ioutil"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCopy(t *testing.T) {
@@ -47,6 +50,51 @@ world,world
 world,world
 `),
 		},
+		{
+			Name: "CSVWithHeaderToCSV",
+			Reader: NewCSVReader(strings.NewReader(`hello,goodbye
+world,world
+`), CSVHeader(true)),
+			Writer: func(w io.Writer) Writer { return NewCSVWriter(w) },
+			Expected: strings.NewReader(`hello,goodbye
+world,world
+`),
+		},
+		{
+			Name: "CSVWithHeaderToCSVWithoutHeader",
+			Reader: NewCSVReader(strings.NewReader(`hello,goodbye
+world,world
+`), CSVHeader(true)),
+			Writer: func(w io.Writer) Writer { return NewCSVWriter(w, CSVHeader(false)) },
+			Expected: strings.NewReader(`world,world
+`),
+		},
+		{
+			Name: "TSVToCSV",
+			Reader: NewCSVReader(strings.NewReader("# comment\nhello\t goodbye\nworld\t \"world\"\n"),
+				CSVDelimiter('\t'), CSVComment('#'), CSVTrimLeadingSpace(true)),
+			Writer: func(w io.Writer) Writer { return NewCSVWriter(w) },
+			Expected: strings.NewReader(`hello,goodbye
+world,world
+`),
+		},
+		{
+			Name: "VariableFieldsAndLazyQuotes",
+			Reader: NewCSVReader(strings.NewReader("a,b\"c\nd\n"),
+				CSVFieldsPerRecord(-1), CSVLazyQuotes(true)),
+			Writer: func(w io.Writer) Writer { return NewCSVWriter(w, CSVDelimiter(';')) },
+			Expected: strings.NewReader(`a;"b""c"
+d
+`),
+		},
+		{
+			Name:   "AlwaysQuoteWithCRLF",
+			Reader: NewCSVReader(strings.NewReader("id,name\n0,\"tony \"\"iron man\"\" stark\"\n"), CSVHeader(true)),
+			Writer: func(w io.Writer) Writer {
+				return NewCSVWriter(w, CSVAlwaysQuote(true), CSVUseCRLF(true), CSVDelimiter('|'))
+			},
+			Expected: strings.NewReader("\"id\"|\"name\"\r\n\"0\"|\"tony \"\"iron man\"\" stark\"\r\n"),
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -72,3 +120,210 @@ world,world
 		})
 	}
 }
+
+func TestCopyPipeline(t *testing.T) {
+	records := make([][]string, 0, 100)
+	for i := 0; i < cap(records); i++ {
+		records = append(records, []string{strconv.Itoa(i)})
+	}
+
+	t.Run("CopiesAllRecords", func(subT *testing.T) {
+		w := NewRecordsWriter()
+		err := Copy(w, NewRecordsReader(records...), Pipeline(10))
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		actual := w.Records()
+		if len(records) != len(actual) {
+			subT.Logf("expected %d records\ngot: %d", len(records), len(actual))
+			subT.Fail()
+			return
+		}
+		for i := range records {
+			if records[i][0] != actual[i][0] {
+				subT.Logf("expected: %v\ngot: %v", records[i], actual[i])
+				subT.Fail()
+				return
+			}
+		}
+	})
+
+	t.Run("ReturnsWriterError", func(subT *testing.T) {
+		expected := errors.New("write failed")
+		w := &failingWriter{Writer: NewRecordsWriter(), after: 5, err: expected}
+
+		err := Copy(w, NewRecordsReader(records...), Pipeline(1))
+		if err != expected {
+			subT.Logf("expected: %v\ngot: %v", expected, err)
+			subT.Fail()
+			return
+		}
+	})
+
+	t.Run("ReturnsReaderError", func(subT *testing.T) {
+		expected := errors.New("read failed")
+		r := &failingReader{Reader: NewRecordsReader(records...), after: 5, err: expected}
+
+		err := Copy(NewRecordsWriter(), r, Pipeline(1))
+		if err != expected {
+			subT.Logf("expected: %v\ngot: %v", expected, err)
+			subT.Fail()
+			return
+		}
+	})
+}
+
+// failingWriter fails every write after the first n records.
+type failingWriter struct {
+	Writer
+
+	after int
+	err   error
+}
+
+func (w *failingWriter) Write(record []string) error {
+	if w.after == 0 {
+		return w.err
+	}
+	w.after -= 1
+	return w.Writer.Write(record)
+}
+
+// failingReader fails every read after the first n records.
+type failingReader struct {
+	Reader
+
+	after int
+	err   error
+}
+
+func (r *failingReader) Read() ([]string, error) {
+	if r.after == 0 {
+		return nil, r.err
+	}
+	r.after -= 1
+	return r.Reader.Read()
+}
+
+func TestCopyStats(t *testing.T) {
+	records := [][]string{
+		{"0", "tony"},
+		{"1", "clark"},
+		{"2", "bruce"},
+	}
+
+	t.Run("Success", func(subT *testing.T) {
+		var stats Stats
+		var progressed []Stats
+		err := Copy(
+			NewRecordsWriter(),
+			NewRecordsReader(records...),
+			WithStats(&stats),
+			Progress(time.Hour, func(s Stats) { progressed = append(progressed, s) }),
+		)
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		if stats.RecordsRead != 3 || stats.RecordsWritten != 3 || stats.Bytes != 17 || stats.FailedRecord != -1 {
+			subT.Logf("unexpected stats: %+v", stats)
+			subT.Fail()
+			return
+		}
+		if len(progressed) != 1 || progressed[0] != stats {
+			subT.Logf("expected final progress report: %+v\ngot: %+v", stats, progressed)
+			subT.Fail()
+			return
+		}
+	})
+
+	for _, bufferSize := range []int{0, 1} {
+		t.Run("FailedWrite/Pipeline"+strconv.Itoa(bufferSize), func(subT *testing.T) {
+			var stats Stats
+			w := &failingWriter{Writer: NewRecordsWriter(), after: 1, err: errors.New("write failed")}
+
+			err := Copy(w, NewRecordsReader(records...), WithStats(&stats), Pipeline(bufferSize))
+			if err == nil {
+				subT.Log("expected copy to fail")
+				subT.Fail()
+				return
+			}
+
+			if stats.RecordsWritten != 1 || stats.FailedRecord != 1 {
+				subT.Logf("unexpected stats: %+v", stats)
+				subT.Fail()
+				return
+			}
+		})
+	}
+}
+
+func TestCopySkipErrors(t *testing.T) {
+	input := `id,name
+0,tony
+1,clark,kent
+2,bruce
+`
+
+	t.Run("AbortsByDefault", func(subT *testing.T) {
+		var stats Stats
+		err := Copy(NewRecordsWriter(), NewCSVReader(strings.NewReader(input)), WithStats(&stats))
+		if err == nil {
+			subT.Log("expected copy to fail")
+			subT.Fail()
+			return
+		}
+		if stats.FailedRecord != 2 {
+			subT.Logf("expected failed record: 2\ngot: %d", stats.FailedRecord)
+			subT.Fail()
+			return
+		}
+	})
+
+	t.Run("RejectsFailedRecords", func(subT *testing.T) {
+		var stats Stats
+		w := NewRecordsWriter()
+		rejects := NewRecordsWriter()
+
+		err := Copy(
+			w,
+			NewCSVReader(strings.NewReader(input)),
+			SkipErrors(-1),
+			RejectTo(rejects),
+			WithStats(&stats),
+		)
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		if len(w.Records()) != 3 || stats.RecordsSkipped != 1 {
+			subT.Logf("unexpected records: %v\nstats: %+v", w.Records(), stats)
+			subT.Fail()
+			return
+		}
+
+		rejected := rejects.Records()
+		if len(rejected) != 1 || len(rejected[0]) != 4 || rejected[0][1] != "clark" {
+			subT.Logf("unexpected rejected records: %v", rejected)
+			subT.Fail()
+			return
+		}
+	})
+
+	t.Run("ExceedsBudget", func(subT *testing.T) {
+		w := &failingWriter{Writer: NewRecordsWriter(), after: 1, err: errors.New("write failed")}
+
+		r := NewRecordsReader([]string{"0"}, []string{"1"}, []string{"2"})
+
+		err := Copy(w, r, SkipErrors(1))
+		if err == nil || !errors.Is(err, w.err) {
+			subT.Logf("expected: %v\ngot: %v", w.err, err)
+			subT.Fail()
+			return
+		}
+	})
+}
@@ -0,0 +1,422 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Transform rewrites the records flowing from a Reader. It is given the
+// columns of the Reader once, before any records are read, and returns
+// the resulting columns along with the function to apply to every record.
+// The record is dropped if the function returns nil.
+//
+// Records are given as typed values, or as StringKind values if
+// the Reader is not a TypedReader or they are read with Read.
+//
+type Transform func(columns []Column) ([]Column, func(record []Value) []Value, error)
+
+// TransformReader applies a sequence of Transforms to the records
+// of a Reader.
+//
+// Columns are referred to by name, as given by the schema of the
+// underlying Reader. If it does not provide a schema, its first record
+// is taken to be the header row, which is transformed along with
+// the rest of the records.
+//
+// If the underlying Reader is a TypedReader, records read with ReadTyped
+// keep their typed values through the Transforms.
+//
+type TransformReader struct {
+	r          Reader
+	transforms []Transform
+
+	bound         bool
+	hasSchema     bool
+	headerPending bool
+	columns       []Column
+	apply         func([]Value) []Value
+}
+
+// NewTransformReader
+func NewTransformReader(r Reader, transforms ...Transform) *TransformReader {
+	return &TransformReader{
+		r:          r,
+		transforms: transforms,
+	}
+}
+
+// Read
+func (r *TransformReader) Read() ([]string, error) {
+	return r.ReadContext(context.Background())
+}
+
+// ReadContext
+func (r *TransformReader) ReadContext(ctx context.Context) ([]string, error) {
+	if !r.bound {
+		err := r.bind(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.headerPending {
+		r.headerPending = false
+		return columnNames(r.columns), nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		record, err := readFunc(r.r)(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		vals := r.apply(stringValues(record))
+		if vals != nil {
+			return stringify(vals), nil
+		}
+	}
+}
+
+// ReadTyped
+func (r *TransformReader) ReadTyped() ([]Value, error) {
+	return r.ReadTypedContext(context.Background())
+}
+
+// ReadTypedContext is the same as ReadContext except records are read
+// as typed values, if the underlying Reader is a TypedReader.
+func (r *TransformReader) ReadTypedContext(ctx context.Context) ([]Value, error) {
+	if !r.bound {
+		err := r.bind(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.headerPending {
+		r.headerPending = false
+		return stringValues(columnNames(r.columns)), nil
+	}
+
+	tr, typed := r.r.(TypedReader)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var vals []Value
+		var err error
+		if typed {
+			vals, err = typedReadFunc(tr)(ctx)
+		} else {
+			var record []string
+			record, err = readFunc(r.r)(ctx)
+			vals = stringValues(record)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		vals = r.apply(vals)
+		if vals != nil {
+			return vals, nil
+		}
+	}
+}
+
+// Schema
+func (r *TransformReader) Schema() ([]Column, error) {
+	return r.SchemaContext(context.Background())
+}
+
+// SchemaContext returns the transformed schema of the underlying Reader.
+// ErrNoSchema is returned if the underlying Reader does not provide one.
+func (r *TransformReader) SchemaContext(ctx context.Context) ([]Column, error) {
+	if !r.bound {
+		err := r.bind(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !r.hasSchema {
+		return nil, ErrNoSchema
+	}
+	return r.columns, nil
+}
+
+// Abort aborts the underlying Reader, if it implements Aborter.
+func (r *TransformReader) Abort() error {
+	if a, ok := r.r.(Aborter); ok {
+		return a.Abort()
+	}
+	return nil
+}
+
+func (r *TransformReader) bind(ctx context.Context) error {
+	columns, err := readSchema(ctx, r.r)
+	if err == nil {
+		r.hasSchema = true
+	}
+	if err == ErrNoSchema {
+		var header []string
+		header, err = readFunc(r.r)(ctx)
+		columns = columnsNamed(header)
+	}
+	if err != nil {
+		return err
+	}
+
+	apply := func(record []Value) []Value { return record }
+	for _, transform := range r.transforms {
+		var fn func([]Value) []Value
+		columns, fn, err = transform(columns)
+		if err != nil {
+			return err
+		}
+
+		prev := apply
+		apply = func(record []Value) []Value {
+			record = prev(record)
+			if record == nil {
+				return nil
+			}
+			return fn(record)
+		}
+	}
+
+	r.bound = true
+	r.headerPending = !r.hasSchema
+	r.columns = columns
+	r.apply = apply
+	return nil
+}
+
+// Row provides access to the fields of a record by column name.
+type Row struct {
+	columns map[string]int
+	record  []Value
+}
+
+// Get returns the field of the named column, or an empty string
+// if there is no such column.
+func (r Row) Get(name string) string {
+	return r.Value(name).String()
+}
+
+// Value returns the value of the named column, or a NULL value
+// if there is no such column.
+func (r Row) Value(name string) Value {
+	i, ok := r.columns[name]
+	if !ok {
+		return NullValue(StringKind)
+	}
+	return field(r.record, i)
+}
+
+// Select keeps only the given columns, in the given order. Columns may
+// be referred to by name or by their position, counting from 1.
+func Select(columns ...string) Transform {
+	return func(cols []Column) ([]Column, func([]Value) []Value, error) {
+		idxs, err := columnIndexes(cols, columns)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		selected := make([]Column, len(idxs))
+		for i, idx := range idxs {
+			selected[i] = cols[idx]
+		}
+
+		return selected, func(record []Value) []Value {
+			out := make([]Value, len(idxs))
+			for i, idx := range idxs {
+				out[i] = field(record, idx)
+			}
+			return out
+		}, nil
+	}
+}
+
+// Drop removes the given columns. Columns may be referred to by name
+// or by their position, counting from 1.
+func Drop(columns ...string) Transform {
+	return func(cols []Column) ([]Column, func([]Value) []Value, error) {
+		idxs, err := columnIndexes(cols, columns)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dropped := make(map[int]bool, len(idxs))
+		for _, idx := range idxs {
+			dropped[idx] = true
+		}
+
+		kept := make([]string, 0, len(cols))
+		for i := range cols {
+			if !dropped[i] {
+				kept = append(kept, strconv.Itoa(i+1))
+			}
+		}
+		return Select(kept...)(cols)
+	}
+}
+
+// Rename renames a column. The column may be referred to by name or
+// by its position, counting from 1.
+func Rename(from, to string) Transform {
+	return func(cols []Column) ([]Column, func([]Value) []Value, error) {
+		idx, err := columnIndex(cols, from)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		renamed := make([]Column, len(cols))
+		copy(renamed, cols)
+		renamed[idx].Name = to
+
+		return renamed, func(record []Value) []Value { return record }, nil
+	}
+}
+
+// AddColumn appends a column with the same value for every record.
+func AddColumn(name, value string) Transform {
+	return Compute(name, func(Row) string { return value })
+}
+
+// Compute appends a column whose value is computed from the other
+// fields of each record.
+func Compute(name string, fn func(Row) string) Transform {
+	return func(cols []Column) ([]Column, func([]Value) []Value, error) {
+		index := make(map[string]int, len(cols))
+		for i, col := range cols {
+			index[col.Name] = i
+		}
+
+		added := make([]Column, len(cols), len(cols)+1)
+		copy(added, cols)
+		added = append(added, Column{Name: name, Kind: StringKind, Nullable: true})
+
+		return added, func(record []Value) []Value {
+			out := make([]Value, len(cols), len(cols)+1)
+			for i := range out {
+				out[i] = field(record, i)
+			}
+			return append(out, StringValue(fn(Row{columns: index, record: record})))
+		}, nil
+	}
+}
+
+// TrimSpace removes leading and trailing white space from the fields of
+// the given columns, or of every column if none are given. Only string
+// values are trimmed.
+func TrimSpace(columns ...string) Transform {
+	return func(cols []Column) ([]Column, func([]Value) []Value, error) {
+		idxs, err := columnIndexes(cols, columns)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return cols, func(record []Value) []Value {
+			out := make([]Value, len(record))
+			copy(out, record)
+
+			if len(columns) == 0 {
+				for i := range out {
+					out[i] = trimSpace(out[i])
+				}
+				return out
+			}
+
+			for _, idx := range idxs {
+				if idx < len(out) {
+					out[idx] = trimSpace(out[idx])
+				}
+			}
+			return out
+		}, nil
+	}
+}
+
+// columnIndex resolves a reference to a column by name or by its
+// position, counting from 1.
+func columnIndex(columns []Column, ref string) (int, error) {
+	for i, col := range columns {
+		if col.Name == ref {
+			return i, nil
+		}
+	}
+
+	pos, err := strconv.Atoi(ref)
+	if err == nil && pos > 0 && pos <= len(columns) {
+		return pos - 1, nil
+	}
+	return 0, fmt.Errorf("tblconv: unknown column: %s", ref)
+}
+
+func columnIndexes(columns []Column, refs []string) ([]int, error) {
+	idxs := make([]int, len(refs))
+	for i, ref := range refs {
+		idx, err := columnIndex(columns, ref)
+		if err != nil {
+			return nil, err
+		}
+		idxs[i] = idx
+	}
+	return idxs, nil
+}
+
+func trimSpace(val Value) Value {
+	if s, ok := val.V.(string); ok {
+		val.V = strings.TrimSpace(s)
+	}
+	return val
+}
+
+// field returns the i-th field of record, or a NULL value if record
+// is too short.
+func field(record []Value, i int) Value {
+	if i < len(record) {
+		return record[i]
+	}
+	return NullValue(StringKind)
+}
+
+func stringValues(record []string) []Value {
+	if record == nil {
+		return nil
+	}
+
+	vals := make([]Value, len(record))
+	for i, field := range record {
+		vals[i] = StringValue(field)
+	}
+	return vals
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTransformReader(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Reader     func() Reader
+		Transforms []Transform
+		Expected   [][]string
+	}{
+		{
+			Name: "SelectByNameAndPosition",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"id", "first", "last"}, []string{"0", "tony", "stark"})
+			},
+			Transforms: []Transform{Select("last", "1")},
+			Expected:   [][]string{{"last", "id"}, {"stark", "0"}},
+		},
+		{
+			Name: "DropAndRename",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"id", "first", "last"}, []string{"0", "tony", "stark"})
+			},
+			Transforms: []Transform{Drop("id"), Rename("first", "name")},
+			Expected:   [][]string{{"name", "last"}, {"tony", "stark"}},
+		},
+		{
+			Name: "AddAndCompute",
+			Reader: func() Reader {
+				return NewRecordsReader([]string{"first", "last"}, []string{"tony", "stark"})
+			},
+			Transforms: []Transform{
+				AddColumn("team", "avengers"),
+				Compute("full", func(row Row) string { return row.Get("first") + " " + row.Get("last") }),
+			},
+			Expected: [][]string{{"first", "last", "team", "full"}, {"tony", "stark", "avengers", "tony stark"}},
+		},
+		{
+			Name: "TrimSpaceWithSchema",
+			Reader: func() Reader {
+				return NewCSVReader(strings.NewReader("id,name\n 0 , tony \n"), CSVHeader(true))
+			},
+			Transforms: []Transform{TrimSpace("name")},
+			Expected:   [][]string{{"id", "name"}, {" 0 ", "tony"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			var out strings.Builder
+			w := NewCSVWriter(&out)
+
+			err := Copy(w, NewTransformReader(testCase.Reader(), testCase.Transforms...))
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			var expected strings.Builder
+			ew := NewCSVWriter(&expected)
+			for _, record := range testCase.Expected {
+				ew.Write(record)
+			}
+			ew.Flush()
+
+			if expected.String() != out.String() {
+				subT.Logf("expected: %q\ngot: %q", expected.String(), out.String())
+				subT.Fail()
+				return
+			}
+		})
+	}
+
+	t.Run("UnknownColumn", func(subT *testing.T) {
+		r := NewTransformReader(NewRecordsReader([]string{"id"}), Select("name"))
+
+		_, err := r.Read()
+		if err == nil {
+			subT.Log("expected an error for an unknown column")
+			subT.Fail()
+			return
+		}
+	})
+}
+
+func TestTransformReaderTyped(t *testing.T) {
+	where, err := ParseExpr("age > 30")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	r := NewTransformReader(
+		NewInferReader(NewRecordsReader(
+			[]string{"name", "age", "joined"},
+			[]string{" tony ", "52", "2008-05-02"},
+			[]string{"peter", "17", "2016-05-06"},
+		)),
+		Drop("joined"),
+		TrimSpace(),
+		Where(where),
+		Rename("age", "years"),
+	)
+
+	var records [][]Value
+	for {
+		record, err := r.ReadTyped()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		records = append(records, record)
+	}
+
+	expected := [][]Value{{StringValue("tony"), IntValue(52)}}
+	if fmt.Sprintf("%#v", expected) != fmt.Sprintf("%#v", records) {
+		t.Logf("expected: %#v\ngot: %#v", expected, records)
+		t.Fail()
+	}
+
+	columns, err := r.Schema()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if columns[1].Name != "years" || columns[1].Kind != IntKind {
+		t.Logf("expected an IntKind column named years\ngot: %v", columns[1])
+		t.Fail()
+	}
+}
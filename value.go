@@ -0,0 +1,358 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind identifies the type of data held by a Value.
+type Kind uint8
+
+const (
+	StringKind Kind = iota
+	IntKind
+	FloatKind
+	DecimalKind
+	BoolKind
+	DateKind
+	TimeKind
+	BytesKind
+)
+
+var kindNames = [...]string{
+	StringKind:  "string",
+	IntKind:     "int",
+	FloatKind:   "float",
+	DecimalKind: "decimal",
+	BoolKind:    "bool",
+	DateKind:    "date",
+	TimeKind:    "time",
+	BytesKind:   "bytes",
+}
+
+// String
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Value is a single typed field of a record.
+//
+// V holds the native Go representation of the value, which depends on Kind:
+//
+//	StringKind   string
+//	IntKind      int64
+//	FloatKind    float64
+//	DecimalKind  string
+//	BoolKind     bool
+//	DateKind     time.Time
+//	TimeKind     time.Time
+//	BytesKind    []byte
+//
+// When Null is true, V is nil and Kind describes the type of the column
+// the NULL was read from, if known.
+//
+type Value struct {
+	Kind Kind
+	Null bool
+	V    interface{}
+}
+
+// NullValue
+func NullValue(k Kind) Value {
+	return Value{Kind: k, Null: true}
+}
+
+// StringValue
+func StringValue(s string) Value {
+	return Value{Kind: StringKind, V: s}
+}
+
+// IntValue
+func IntValue(i int64) Value {
+	return Value{Kind: IntKind, V: i}
+}
+
+// FloatValue
+func FloatValue(f float64) Value {
+	return Value{Kind: FloatKind, V: f}
+}
+
+// DecimalValue holds an exact decimal number in its textual form, e.g. "12.50".
+func DecimalValue(s string) Value {
+	return Value{Kind: DecimalKind, V: s}
+}
+
+// BoolValue
+func BoolValue(b bool) Value {
+	return Value{Kind: BoolKind, V: b}
+}
+
+// DateValue
+func DateValue(t time.Time) Value {
+	return Value{Kind: DateKind, V: t}
+}
+
+// TimeValue
+func TimeValue(t time.Time) Value {
+	return Value{Kind: TimeKind, V: t}
+}
+
+// BytesValue
+func BytesValue(b []byte) Value {
+	return Value{Kind: BytesKind, V: b}
+}
+
+// String formats the Value as text. NULL values are formatted as an
+// empty string.
+func (v Value) String() string {
+	if v.Null || v.V == nil {
+		return ""
+	}
+
+	switch x := v.V.(type) {
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return formatFloat(x)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		if v.Kind == DateKind {
+			return x.Format(dateLayout)
+		}
+		return x.Format(time.RFC3339Nano)
+	case []byte:
+		return string(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// driverValue returns v as one of the types accepted by database/sql
+// for query arguments.
+func (v Value) driverValue() interface{} {
+	if v.Null {
+		return nil
+	}
+	return v.V
+}
+
+const dateLayout = "2006-01-02"
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	dateLayout,
+}
+
+// ParseValue converts s into a Value of the given Kind.
+func ParseValue(s string, k Kind) (Value, error) {
+	switch k {
+	case StringKind:
+		return StringValue(s), nil
+	case IntKind:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return Value{}, err
+		}
+		return IntValue(i), nil
+	case FloatKind:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return Value{}, err
+		}
+		return FloatValue(f), nil
+	case DecimalKind:
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return Value{}, err
+		}
+		return DecimalValue(s), nil
+	case BoolKind:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return Value{}, err
+		}
+		return BoolValue(b), nil
+	case DateKind:
+		t, err := parseTime(s)
+		if err != nil {
+			return Value{}, err
+		}
+		return DateValue(t), nil
+	case TimeKind:
+		t, err := parseTime(s)
+		if err != nil {
+			return Value{}, err
+		}
+		return TimeValue(t), nil
+	case BytesKind:
+		return BytesValue([]byte(s)), nil
+	default:
+		return Value{}, fmt.Errorf("tblconv: unknown kind: %s", k)
+	}
+}
+
+func parseTime(s string) (t time.Time, err error) {
+	for _, layout := range timeLayouts {
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return
+		}
+	}
+	return
+}
+
+// valueOf wraps a value returned by a database/sql driver.
+func valueOf(v interface{}) Value {
+	switch x := v.(type) {
+	case nil:
+		return NullValue(StringKind)
+	case string:
+		return StringValue(x)
+	case int64:
+		return IntValue(x)
+	case float64:
+		return FloatValue(x)
+	case bool:
+		return BoolValue(x)
+	case time.Time:
+		return TimeValue(x)
+	case []byte:
+		return BytesValue(x)
+	default:
+		return StringValue(fmt.Sprint(x))
+	}
+}
+
+// convertValue wraps a driver value, coercing it into Kind k. Values which
+// cannot be represented as k are wrapped as is.
+func convertValue(v interface{}, k Kind) Value {
+	if v == nil {
+		return NullValue(k)
+	}
+
+	val := valueOf(v)
+	if val.Kind == k {
+		return val
+	}
+
+	switch x := v.(type) {
+	case time.Time:
+		if k == DateKind {
+			return DateValue(x)
+		}
+	case int64:
+		switch k {
+		case FloatKind:
+			return FloatValue(float64(x))
+		case DecimalKind:
+			return DecimalValue(strconv.FormatInt(x, 10))
+		}
+	case float64:
+		if k == DecimalKind {
+			return DecimalValue(formatFloat(x))
+		}
+	case string, []byte:
+		if parsed, err := ParseValue(val.String(), k); err == nil {
+			return parsed
+		}
+	}
+	return val
+}
+
+func formatFloat(f float64) string {
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// canonicalDecimal formats a decimal number without exponent, sign
+// of zero or insignificant zeros, e.g. "+012.50" as "12.5" and
+// "1.5E3" as "1500". s is returned as is if it is not a number.
+func canonicalDecimal(s string) string {
+	num := strings.TrimSpace(s)
+
+	neg := false
+	switch {
+	case strings.HasPrefix(num, "-"):
+		neg = true
+		num = num[1:]
+	case strings.HasPrefix(num, "+"):
+		num = num[1:]
+	}
+
+	exp := 0
+	if i := strings.IndexAny(num, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.Atoi(num[i+1:])
+		if err != nil || exp > 1000 || exp < -1000 {
+			return s
+		}
+		num = num[:i]
+	}
+
+	intPart, fracPart, _ := strings.Cut(num, ".")
+	digits := intPart + fracPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return s
+	}
+
+	// position of the decimal point within digits
+	point := len(intPart) + exp
+	for point < 0 {
+		digits = "0" + digits
+		point++
+	}
+	for point > len(digits) {
+		digits += "0"
+	}
+
+	intPart = strings.TrimLeft(digits[:point], "0")
+	fracPart = strings.TrimRight(digits[point:], "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	out := intPart
+	if fracPart != "" {
+		out += "." + fracPart
+	}
+	if neg && out != "0" {
+		out = "-" + out
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseValue(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Kind     Kind
+		Expected Value
+	}{
+		{
+			Name:     "Int",
+			Input:    "-23",
+			Kind:     IntKind,
+			Expected: IntValue(-23),
+		},
+		{
+			Name:     "Float",
+			Input:    "1.5",
+			Kind:     FloatKind,
+			Expected: FloatValue(1.5),
+		},
+		{
+			Name:     "Decimal",
+			Input:    "12.50",
+			Kind:     DecimalKind,
+			Expected: DecimalValue("12.50"),
+		},
+		{
+			Name:     "Bool",
+			Input:    "true",
+			Kind:     BoolKind,
+			Expected: BoolValue(true),
+		},
+		{
+			Name:     "Date",
+			Input:    "2021-03-04",
+			Kind:     DateKind,
+			Expected: DateValue(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			Name:     "Time",
+			Input:    "2021-03-04 05:06:07",
+			Kind:     TimeKind,
+			Expected: TimeValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			actual, err := ParseValue(testCase.Input, testCase.Kind)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+
+			if actual.Kind != testCase.Expected.Kind || actual.String() != testCase.Expected.String() {
+				subT.Logf("expected: %v\ngot: %v", testCase.Expected, actual)
+				subT.Fail()
+				return
+			}
+		})
+	}
+}
+
+func TestValueString(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Value    Value
+		Expected string
+	}{
+		{
+			Name:     "Null",
+			Value:    NullValue(IntKind),
+			Expected: "",
+		},
+		{
+			Name:     "Float",
+			Value:    FloatValue(1234567.25),
+			Expected: "1234567.25",
+		},
+		{
+			Name:     "LargeFloat",
+			Value:    FloatValue(1e300),
+			Expected: "1e+300",
+		},
+		{
+			Name:     "Date",
+			Value:    DateValue(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)),
+			Expected: "2021-03-04",
+		},
+		{
+			Name:     "Time",
+			Value:    TimeValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)),
+			Expected: "2021-03-04T05:06:07Z",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(subT *testing.T) {
+			actual := testCase.Value.String()
+			if testCase.Expected != actual {
+				subT.Logf("expected: %s\ngot: %s", testCase.Expected, actual)
+				subT.Fail()
+				return
+			}
+		})
+	}
+}
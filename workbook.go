@@ -0,0 +1,109 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Workbook writes the records of several Readers into one Excel
+// workbook, each in its own sheet.
+type Workbook struct {
+	out    io.Writer
+	opts   []ExcelOption
+	sheets []workbookSheet
+}
+
+type workbookSheet struct {
+	name string
+	r    Reader
+	opts []ExcelOption
+}
+
+// NewWorkbook returns a Workbook which writes to w. The options apply
+// to every sheet, before any options given to AddSheet.
+func NewWorkbook(w io.Writer, opts ...ExcelOption) *Workbook {
+	return &Workbook{
+		out:  w,
+		opts: opts,
+	}
+}
+
+// AddSheet adds a sheet with the given name, which will hold the
+// records of r. Sheets appear in the workbook in the order they're added.
+func (b *Workbook) AddSheet(name string, r Reader, opts ...ExcelOption) {
+	b.sheets = append(b.sheets, workbookSheet{name: name, r: r, opts: opts})
+}
+
+// Write
+func (b *Workbook) Write(opts ...CopyOption) error {
+	return b.WriteContext(context.Background(), opts...)
+}
+
+// WriteContext copies every Reader into its sheet, in order, and then
+// writes the workbook. The CopyOptions apply to each copy separately.
+// Nothing is written if any copy fails.
+func (b *Workbook) WriteContext(ctx context.Context, opts ...CopyOption) error {
+	if len(b.sheets) == 0 {
+		return fmt.Errorf("tblconv: workbook has no sheets")
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	seen := make(map[string]bool, len(b.sheets))
+	for i, sheet := range b.sheets {
+		if seen[sheet.name] {
+			return fmt.Errorf("tblconv: duplicate sheet: %s", sheet.name)
+		}
+		seen[sheet.name] = true
+
+		if i == 0 {
+			f.SetSheetName("Sheet1", sheet.name)
+		} else {
+			f.NewSheet(sheet.name)
+		}
+
+		sheetOpts := append(append([]ExcelOption{}, b.opts...), sheet.opts...)
+		sheetOpts = append(sheetOpts, SheetName(sheet.name))
+
+		w := &ExcelWriter{
+			cfg:    newExcelWriterConfig(sheetOpts),
+			excel:  f,
+			styles: make(map[string]int),
+		}
+
+		err := CopyContext(ctx, w, sheet.r, opts...)
+		if err != nil {
+			return fmt.Errorf("tblconv: sheet %s: %w", sheet.name, err)
+		}
+	}
+
+	f.SetActiveSheet(0)
+	_, err := f.WriteTo(b.out)
+	return err
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWorkbook(t *testing.T) {
+	t.Run("SheetPerReader", func(subT *testing.T) {
+		var buf bytes.Buffer
+		b := NewWorkbook(&buf)
+		b.AddSheet("users", NewCSVReader(strings.NewReader("id,name\n0,tony\n"), CSVHeader(true)))
+		b.AddSheet("orders", NewRecordsReader([]string{"id", "user"}, []string{"100", "0"}, []string{"101", "0"}))
+
+		err := b.Write()
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		f, err := excelize.OpenReader(&buf)
+		if err != nil {
+			subT.Error(err)
+			return
+		}
+
+		if sheets := f.GetSheetList(); fmt.Sprint(sheets) != "[users orders]" {
+			subT.Logf("expected sheets: [users orders]\ngot: %v", sheets)
+			subT.Fail()
+			return
+		}
+
+		expected := map[string][][]string{
+			"users":  {{"id", "name"}, {"0", "tony"}},
+			"orders": {{"id", "user"}, {"100", "0"}, {"101", "0"}},
+		}
+		for sheet, rows := range expected {
+			actual, err := f.GetRows(sheet)
+			if err != nil {
+				subT.Error(err)
+				return
+			}
+			if fmt.Sprint(rows) != fmt.Sprint(actual) {
+				subT.Logf("%s: expected: %q\ngot: %q", sheet, rows, actual)
+				subT.Fail()
+			}
+		}
+	})
+
+	t.Run("DuplicateSheet", func(subT *testing.T) {
+		var buf bytes.Buffer
+		b := NewWorkbook(&buf)
+		b.AddSheet("users", NewRecordsReader())
+		b.AddSheet("users", NewRecordsReader())
+
+		err := b.Write()
+		if err == nil {
+			subT.Log("expected an error for a duplicate sheet")
+			subT.Fail()
+			return
+		}
+		if buf.Len() > 0 {
+			subT.Log("expected nothing to be written")
+			subT.Fail()
+		}
+	})
+}
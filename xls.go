@@ -0,0 +1,613 @@
+/*
+Copyright © 2021 Zaba505
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package tblconv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSReader reads the sheets of a legacy Excel 97-2003 workbook (.xls)
+// in the BIFF8 format. It accepts the same options as an ExcelReader,
+// except for DefinedName, Formulas and FillMergedCells: formula cells
+// are always read as their cached values. Without SheetName, the first
+// sheet is read.
+//
+// Numbers are read without their number formats applied, except for
+// dates and times, which are read as "2006-01-02", "15:04:05" or
+// "2006-01-02 15:04:05". Booleans are read as "TRUE" or "FALSE".
+//
+// A sheet is held in memory while it is read.
+type XLSReader struct {
+	sheetReader
+}
+
+// NewXLSReader
+func NewXLSReader(r io.Reader, opts ...ExcelOption) *XLSReader {
+	var cfg excelConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &XLSReader{
+		sheetReader: sheetReader{
+			cfg:  cfg,
+			open: func() (spreadsheet, error) { return openXLS(r) },
+		},
+	}
+}
+
+// ErrXLSEncrypted is returned when reading a password protected .xls workbook.
+var ErrXLSEncrypted = errors.New("tblconv: xls workbook is encrypted")
+
+// BIFF8 record types
+const (
+	biffFormula    = 0x0006
+	biffEOF        = 0x000a
+	biffDateMode   = 0x0022
+	biffFilePass   = 0x002f
+	biffContinue   = 0x003c
+	biffBoundSheet = 0x0085
+	biffMulRK      = 0x00bd
+	biffRString    = 0x00d6
+	biffXF         = 0x00e0
+	biffSST        = 0x00fc
+	biffLabelSST   = 0x00fd
+	biffNumber     = 0x0203
+	biffLabel      = 0x0204
+	biffBoolErr    = 0x0205
+	biffString     = 0x0207
+	biffArray      = 0x0221
+	biffTable      = 0x0236
+	biffRK         = 0x027e
+	biffFormat     = 0x041e
+	biffShrFmla    = 0x04bc
+	biffBOF        = 0x0809
+)
+
+type xlsWorkbook struct {
+	stream   []byte
+	names    []string
+	offsets  []uint32
+	strings  []string
+	xfs      []uint16
+	formats  map[uint16]string
+	date1904 bool
+}
+
+func openXLS(r io.Reader) (*xlsWorkbook, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := mscfb.New(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("tblconv: not an xls workbook: %w", err)
+	}
+
+	var stream []byte
+	for _, f := range doc.File {
+		if len(f.Path) == 0 && (f.Name == "Workbook" || f.Name == "Book") {
+			stream, err = io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if stream == nil {
+		return nil, errors.New("tblconv: not an xls workbook: no Workbook stream")
+	}
+
+	wb := &xlsWorkbook{
+		stream:  stream,
+		formats: make(map[uint16]string),
+	}
+	return wb, wb.parseGlobals()
+}
+
+// biffRecord is a record of a BIFF stream, along with
+// the CONTINUE records following it.
+type biffRecord struct {
+	typ  uint16
+	data [][]byte
+}
+
+// biffRecords iterates over the records of a BIFF stream,
+// starting at a given offset.
+type biffRecords struct {
+	stream []byte
+	off    int
+}
+
+func (it *biffRecords) next() (biffRecord, error) {
+	var rec biffRecord
+	for {
+		if it.off+4 > len(it.stream) {
+			if rec.data != nil {
+				return rec, nil
+			}
+			return rec, io.ErrUnexpectedEOF
+		}
+
+		typ := binary.LittleEndian.Uint16(it.stream[it.off:])
+		size := int(binary.LittleEndian.Uint16(it.stream[it.off+2:]))
+		if rec.data != nil && typ != biffContinue {
+			return rec, nil
+		}
+		if it.off+4+size > len(it.stream) {
+			return rec, io.ErrUnexpectedEOF
+		}
+
+		if rec.data == nil {
+			rec.typ = typ
+		}
+		rec.data = append(rec.data, it.stream[it.off+4:it.off+4+size])
+		it.off += 4 + size
+	}
+}
+
+func (wb *xlsWorkbook) parseGlobals() error {
+	it := &biffRecords{stream: wb.stream}
+
+	rec, err := it.next()
+	if err != nil {
+		return err
+	}
+	if rec.typ != biffBOF || len(rec.data[0]) < 2 || binary.LittleEndian.Uint16(rec.data[0]) != 0x0600 {
+		return errors.New("tblconv: unsupported xls version, only BIFF8 (Excel 97 and later) is supported")
+	}
+
+	for {
+		rec, err := it.next()
+		if err != nil {
+			return err
+		}
+
+		b := &biffReader{segs: rec.data}
+		switch rec.typ {
+		case biffEOF:
+			return nil
+		case biffFilePass:
+			return ErrXLSEncrypted
+		case biffDateMode:
+			wb.date1904 = b.u16() == 1
+		case biffBoundSheet:
+			offset := b.u32()
+			b.u8()
+			kind := b.u8()
+			cch := int(b.u8())
+			name := b.chars(cch)
+			if kind == 0 {
+				wb.names = append(wb.names, name)
+				wb.offsets = append(wb.offsets, offset)
+			}
+		case biffFormat:
+			id := b.u16()
+			wb.formats[id] = b.str()
+		case biffXF:
+			b.u16()
+			wb.xfs = append(wb.xfs, b.u16())
+		case biffSST:
+			b.u32()
+			n := int(b.u32())
+			// every string takes at least 3 bytes, so a corrupt
+			// count cannot allocate more than the record holds
+			if limit := b.len() / 3; n > limit {
+				n = limit
+			}
+			wb.strings = make([]string, 0, n)
+			for i := 0; i < n && b.err == nil; i++ {
+				wb.strings = append(wb.strings, b.richStr())
+			}
+		}
+		if b.err != nil {
+			return fmt.Errorf("tblconv: invalid xls record %#04x: %w", rec.typ, b.err)
+		}
+	}
+}
+
+func (wb *xlsWorkbook) sheetNames() []string {
+	return wb.names
+}
+
+func (wb *xlsWorkbook) rows(sheet string) (sheetRows, error) {
+	i := 0
+	for wb.names[i] != sheet {
+		i++
+	}
+
+	it := &biffRecords{stream: wb.stream, off: int(wb.offsets[i])}
+	rec, err := it.next()
+	if err != nil {
+		return nil, err
+	}
+	if rec.typ != biffBOF {
+		return nil, fmt.Errorf("tblconv: invalid xls sheet: %s", sheet)
+	}
+
+	rows := make(map[int][]string)
+	set := func(row, col uint16, value string) {
+		if value == "" {
+			return
+		}
+		r := rows[int(row)+1]
+		for len(r) <= int(col) {
+			r = append(r, "")
+		}
+		r[col] = value
+		rows[int(row)+1] = r
+	}
+
+	for {
+		rec, err := it.next()
+		if err != nil {
+			return nil, err
+		}
+
+		b := &biffReader{segs: rec.data}
+		switch rec.typ {
+		case biffEOF:
+			nums := make([]int, 0, len(rows))
+			for num := range rows {
+				nums = append(nums, num)
+			}
+			sort.Ints(nums)
+			return &gridRows{nums: nums, rows: rows}, nil
+		case biffBOF:
+			// skip embedded substreams, e.g. charts
+			for rec.typ != biffEOF {
+				rec, err = it.next()
+				if err != nil {
+					return nil, err
+				}
+			}
+		case biffLabelSST:
+			row, col := b.u16(), b.u16()
+			b.u16()
+			if i := int(b.u32()); b.err == nil && i < len(wb.strings) {
+				set(row, col, wb.strings[i])
+			}
+		case biffLabel, biffRString:
+			row, col := b.u16(), b.u16()
+			b.u16()
+			set(row, col, b.str())
+		case biffNumber:
+			row, col, xf := b.u16(), b.u16(), b.u16()
+			set(row, col, wb.number(math.Float64frombits(b.u64()), xf))
+		case biffRK:
+			row, col, xf := b.u16(), b.u16(), b.u16()
+			set(row, col, wb.number(rkNumber(b.u32()), xf))
+		case biffMulRK:
+			row, col := b.u16(), b.u16()
+			for n := (b.len() - 2) / 6; n > 0 && b.err == nil; n-- {
+				xf := b.u16()
+				set(row, col, wb.number(rkNumber(b.u32()), xf))
+				col++
+			}
+		case biffBoolErr:
+			row, col := b.u16(), b.u16()
+			b.u16()
+			value, isErr := b.u8(), b.u8()
+			set(row, col, boolErr(value, isErr == 1))
+		case biffFormula:
+			row, col, xf := b.u16(), b.u16(), b.u16()
+			result := b.bytes(8)
+			if b.err != nil {
+				break
+			}
+			if result[6] != 0xff || result[7] != 0xff {
+				set(row, col, wb.number(math.Float64frombits(binary.LittleEndian.Uint64(result)), xf))
+				break
+			}
+
+			switch result[0] {
+			case 0:
+				// the string result follows in a STRING record, after
+				// the formula of a shared, array or table formula
+				rec, err = it.next()
+				for err == nil && (rec.typ == biffShrFmla || rec.typ == biffArray || rec.typ == biffTable) {
+					rec, err = it.next()
+				}
+				if err != nil {
+					return nil, err
+				}
+				if rec.typ == biffString {
+					b = &biffReader{segs: rec.data}
+					set(row, col, b.str())
+				}
+			case 1:
+				set(row, col, boolErr(result[2], false))
+			case 2:
+				set(row, col, boolErr(result[2], true))
+			}
+		}
+		if b.err != nil {
+			return nil, fmt.Errorf("tblconv: invalid xls record %#04x in sheet %s: %w", rec.typ, sheet, b.err)
+		}
+	}
+}
+
+func (wb *xlsWorkbook) close() error {
+	return nil
+}
+
+// number formats a number, as a date or time if the
+// cell has a date or time number format.
+func (wb *xlsWorkbook) number(f float64, xf uint16) string {
+	if int(xf) < len(wb.xfs) && isDateFormat(wb.xfs[xf], wb.formats[wb.xfs[xf]]) {
+		t, err := excelize.ExcelDateToTime(f, wb.date1904)
+		if err == nil {
+			switch {
+			case f < 1:
+				return t.Format("15:04:05")
+			case f == math.Trunc(f):
+				return t.Format("2006-01-02")
+			default:
+				return t.Format("2006-01-02 15:04:05")
+			}
+		}
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// isDateFormat reports whether a number format, given by its
+// id and, unless it is built in, its format string, is a date
+// or time format.
+func isDateFormat(id uint16, format string) bool {
+	switch {
+	case id >= 14 && id <= 22, id >= 27 && id <= 36, id >= 45 && id <= 47, id >= 50 && id <= 58:
+		return true
+	case id < 164:
+		return false
+	}
+
+	// leave out quoted text, escaped characters and
+	// colors or conditions in brackets
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; c {
+		case '"':
+			for i++; i < len(format) && format[i] != '"'; i++ {
+			}
+		case '\\', '_', '*':
+			i++
+		case '[':
+			j := strings.IndexByte(format[i:], ']')
+			if j < 0 {
+				return false
+			}
+			if elapsed := strings.ToLower(format[i+1 : i+j]); strings.Trim(elapsed, "hms") == "" {
+				sb.WriteString(elapsed)
+			}
+			i += j
+		case ';':
+			// the first section is the format of positive numbers
+			i = len(format)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return strings.ContainsAny(strings.ToLower(sb.String()), "ydhms")
+}
+
+// rkNumber decodes an RK value, i.e. a compressed number.
+func rkNumber(rk uint32) float64 {
+	var f float64
+	if rk&0x02 != 0 {
+		f = float64(int32(rk) >> 2)
+	} else {
+		f = math.Float64frombits(uint64(rk&0xfffffffc) << 32)
+	}
+	if rk&0x01 != 0 {
+		f /= 100
+	}
+	return f
+}
+
+var xlsErrors = map[byte]string{
+	0x00: "#NULL!",
+	0x07: "#DIV/0!",
+	0x0f: "#VALUE!",
+	0x17: "#REF!",
+	0x1d: "#NAME?",
+	0x24: "#NUM!",
+	0x2a: "#N/A",
+	0x2b: "#GETTING_DATA",
+}
+
+func boolErr(value byte, isErr bool) string {
+	switch {
+	case isErr:
+		return xlsErrors[value]
+	case value == 0:
+		return "FALSE"
+	default:
+		return "TRUE"
+	}
+}
+
+// biffReader reads the fields of a record spread over its CONTINUE
+// records. The first error is kept in err, after which every read
+// returns zero values.
+type biffReader struct {
+	segs [][]byte
+	seg  int
+	off  int
+	err  error
+}
+
+func (b *biffReader) len() int {
+	n := 0
+	for _, seg := range b.segs[b.seg:] {
+		n += len(seg)
+	}
+	return n - b.off
+}
+
+// bytes reads n bytes, crossing into the following segments as needed.
+func (b *biffReader) bytes(n int) []byte {
+	if b.err != nil {
+		return make([]byte, n)
+	}
+
+	p := make([]byte, 0, n)
+	for len(p) < n {
+		if b.seg >= len(b.segs) {
+			b.err = io.ErrUnexpectedEOF
+			return make([]byte, n)
+		}
+		seg := b.segs[b.seg][b.off:]
+		if k := n - len(p); len(seg) > k {
+			seg = seg[:k]
+		}
+		p = append(p, seg...)
+		b.off += len(seg)
+		if b.off == len(b.segs[b.seg]) && len(p) < n {
+			b.seg++
+			b.off = 0
+		}
+	}
+	return p
+}
+
+// skip skips n bytes, crossing into the following segments
+// as needed, without reading them.
+func (b *biffReader) skip(n int) {
+	for n > 0 && b.err == nil {
+		if b.seg >= len(b.segs) {
+			b.err = io.ErrUnexpectedEOF
+			return
+		}
+
+		k := len(b.segs[b.seg]) - b.off
+		if k > n {
+			k = n
+		}
+		b.off += k
+		n -= k
+		if n > 0 {
+			b.seg++
+			b.off = 0
+		}
+	}
+}
+
+func (b *biffReader) u8() byte {
+	return b.bytes(1)[0]
+}
+
+func (b *biffReader) u16() uint16 {
+	return binary.LittleEndian.Uint16(b.bytes(2))
+}
+
+func (b *biffReader) u32() uint32 {
+	return binary.LittleEndian.Uint32(b.bytes(4))
+}
+
+func (b *biffReader) u64() uint64 {
+	return binary.LittleEndian.Uint64(b.bytes(8))
+}
+
+// chars reads the flags and characters of a string of cch characters.
+// A string continued in the next segment has its own flags there, so
+// each part may be compressed or not.
+func (b *biffReader) chars(cch int) string {
+	return b.charsWithFlags(cch, b.u8())
+}
+
+func (b *biffReader) charsWithFlags(cch int, flags byte) string {
+	units := make([]uint16, 0, cch)
+	for len(units) < cch && b.err == nil {
+		if b.seg < len(b.segs) && b.off == len(b.segs[b.seg]) {
+			b.seg++
+			b.off = 0
+			flags = b.u8()
+		}
+		if b.seg >= len(b.segs) {
+			b.err = io.ErrUnexpectedEOF
+			break
+		}
+
+		n := cch - len(units)
+		avail := len(b.segs[b.seg]) - b.off
+		if flags&0x01 == 0 {
+			if n > avail {
+				n = avail
+			}
+			for _, c := range b.bytes(n) {
+				units = append(units, uint16(c))
+			}
+			continue
+		}
+
+		if n > avail/2 {
+			n = avail / 2
+		}
+		if n == 0 {
+			b.err = io.ErrUnexpectedEOF
+			break
+		}
+		p := b.bytes(2 * n)
+		for i := 0; i < n; i++ {
+			units = append(units, binary.LittleEndian.Uint16(p[2*i:]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// str reads an XLUnicodeString.
+func (b *biffReader) str() string {
+	return b.chars(int(b.u16()))
+}
+
+// richStr reads an XLUnicodeRichExtendedString, leaving
+// out its formatting runs and phonetic data.
+func (b *biffReader) richStr() string {
+	cch := int(b.u16())
+	flags := b.u8()
+
+	var runs, ext int
+	if flags&0x08 != 0 {
+		runs = int(b.u16())
+	}
+	if flags&0x04 != 0 {
+		ext = int(b.u32())
+	}
+
+	s := b.charsWithFlags(cch, flags)
+	b.skip(4*runs + ext)
+	return s
+}